@@ -3,9 +3,13 @@ module github.com/BBVA/kapow
 go 1.13
 
 require (
+	github.com/GehirnInc/crypt v0.0.0-20230320061759-8cc1b52080c5
+	github.com/go-jose/go-jose/v3 v3.0.5
 	github.com/google/shlex v0.0.0-20181106134648-c34317bd91bf
 	github.com/google/uuid v1.1.2
 	github.com/gorilla/mux v1.8.0
 	github.com/spf13/cobra v1.0.0
+	golang.org/x/crypto v0.20.0
 	gopkg.in/h2non/gock.v1 v1.0.15
+	gopkg.in/yaml.v3 v3.0.1
 )
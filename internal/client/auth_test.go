@@ -0,0 +1,74 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestControlCallsSendTheTokenFromEnvironment(t *testing.T) {
+	os.Setenv("KAPOW_CONTROL_TOKEN", "s3cr3t")
+	defer os.Unsetenv("KAPOW_CONTROL_TOKEN")
+	defer gock.Off()
+
+	gock.New("http://localhost:8080").Get("/routes").
+		MatchHeader("Authorization", "^Bearer s3cr3t$").
+		Reply(http.StatusOK)
+	gock.New("http://localhost:8080").Post("/routes").
+		MatchHeader("Authorization", "^Bearer s3cr3t$").
+		Reply(http.StatusCreated)
+	gock.New("http://localhost:8080").Delete("/routes/ROUTE_ID").
+		MatchHeader("Authorization", "^Bearer s3cr3t$").
+		Reply(http.StatusNoContent)
+
+	if err := ListRoutes("http://localhost:8080", nil); err != nil {
+		t.Errorf("Unexpected error %q", err)
+	}
+	if err := AddRoute("http://localhost:8080", "/hello", "GET", "", "echo", nil, nil); err != nil {
+		t.Errorf("Unexpected error %q", err)
+	}
+	if err := RemoveRoute("http://localhost:8080", "ROUTE_ID"); err != nil {
+		t.Errorf("Unexpected error %q", err)
+	}
+
+	if !gock.IsDone() {
+		t.Error("Expected endpoints not called")
+	}
+}
+
+func TestControlCallsSendNoTokenWhenNotInEnvironment(t *testing.T) {
+	os.Unsetenv("KAPOW_CONTROL_TOKEN")
+	defer gock.Off()
+
+	gock.New("http://localhost:8080").Get("/routes").
+		Reply(http.StatusOK)
+
+	gock.Observe(func(req *http.Request, _ gock.Mock) {
+		if h := req.Header.Get("Authorization"); h != "" {
+			t.Errorf("Unexpected Authorization header %q", h)
+		}
+	})
+	defer gock.Observe(nil)
+
+	if err := ListRoutes("http://localhost:8080", nil); err != nil {
+		t.Errorf("Unexpected error %q", err)
+	}
+}
@@ -0,0 +1,34 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/BBVA/kapow/internal/http"
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// ApplyBatch applies the given operations to the route table of the Kapow!
+// server as a whole, writing the resulting route table to w
+func ApplyBatch(host string, ops []model.Operation, w io.Writer) error {
+	url := host + "/batch"
+	body, _ := json.Marshal(ops)
+	return http.Post(url, "application/json", bytes.NewReader(body), w, withControlToken)
+}
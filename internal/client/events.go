@@ -0,0 +1,58 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/BBVA/kapow/internal/http"
+)
+
+// WatchEvents streams the route table events of the Kapow! server, writing
+// each of them to w as a JSON document in its own line.  It only returns when
+// the stream ends.  The values of the environment variables of the routes are
+// hidden if maskEnv is set.
+func WatchEvents(host string, maskEnv bool, w io.Writer) error {
+	url := host + "/events" + maskEnvQuery(maskEnv)
+	return http.Get(url, "", nil, &eventWriter{w: w}, withControlToken)
+}
+
+// eventWriter extracts the data of the Server-Sent Events written to it
+type eventWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (ew *eventWriter) Write(p []byte) (int, error) {
+	ew.buf = append(ew.buf, p...)
+	for {
+		i := bytes.IndexByte(ew.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := ew.buf[:i]
+		ew.buf = ew.buf[i+1:]
+
+		if bytes.HasPrefix(line, []byte("data:")) {
+			data := bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))
+			if _, err := ew.w.Write(append(data, '\n')); err != nil {
+				return 0, err
+			}
+		}
+	}
+}
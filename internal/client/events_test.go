@@ -0,0 +1,62 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestWatchEventsWritesTheDataOfEachEvent(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").Get("/events").
+		Reply(http.StatusOK).
+		SetHeader("Content-Type", "text/event-stream").
+		BodyString("event: added\ndata: {\"type\":\"added\"}\n\nevent: deleted\ndata: {\"type\":\"deleted\"}\n\n")
+
+	var b bytes.Buffer
+	err := WatchEvents("http://localhost:8080", false, &b)
+
+	if err != nil {
+		t.Errorf("Unexpected error %q", err)
+	}
+	if b.String() != "{\"type\":\"added\"}\n{\"type\":\"deleted\"}\n" {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+	if !gock.IsDone() {
+		t.Error("Expected endpoint not called")
+	}
+}
+
+func TestEventWriterHandlesEventsSplitAcrossWrites(t *testing.T) {
+	var b bytes.Buffer
+	ew := &eventWriter{w: &b}
+
+	_, _ = ew.Write([]byte("event: added\nda"))
+	_, _ = ew.Write([]byte("ta: {}"))
+	if b.Len() != 0 {
+		t.Errorf("Incomplete event written %q", b.String())
+	}
+	_, _ = ew.Write([]byte("\n\n"))
+
+	if b.String() != "{}\n" {
+		t.Errorf("Unexpected output %q", b.String())
+	}
+}
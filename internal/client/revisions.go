@@ -0,0 +1,38 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/BBVA/kapow/internal/http"
+)
+
+// ListRevisions queries the Kapow! server for the revisions of its route
+// table, optionally hiding the values of the environment variables
+func ListRevisions(host string, maskEnv bool, w io.Writer) error {
+	url := host + "/revisions" + maskEnvQuery(maskEnv)
+	return http.Get(url, "", nil, w, withControlToken)
+}
+
+// RestoreRevision rolls back the route table of the Kapow! server to the
+// given revision, writing the resulting route table to w
+func RestoreRevision(host string, number int, w io.Writer) error {
+	url := host + "/revisions/" + strconv.Itoa(number) + "/restore"
+	return http.Post(url, "", nil, w, withControlToken)
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestListRevisionsWritesTheRevisions(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Get("/revisions").
+		Reply(http.StatusOK).
+		BodyString(`[{"number":1}]`)
+
+	var b bytes.Buffer
+	if err := ListRevisions("http://localhost:8080", false, &b); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if b.String() != `[{"number":1}]` {
+		t.Errorf("Output mismatch: got %q", b.String())
+	}
+	if !gock.IsDone() {
+		t.Error("No endpoint called")
+	}
+}
+
+func TestListRevisionsAsksToMaskTheEnv(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Get("/revisions").
+		MatchParam("mask_env", "true").
+		Reply(http.StatusOK).
+		BodyString(`[]`)
+
+	if err := ListRevisions("http://localhost:8080", true, &bytes.Buffer{}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !gock.IsDone() {
+		t.Error("No endpoint called")
+	}
+}
+
+func TestRestoreRevisionErrorNonExistent(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Post("/revisions/42/restore").
+		Reply(http.StatusNotFound).
+		BodyString(`{"reason": "Revision Not Found"}`)
+
+	err := RestoreRevision("http://localhost:8080", 42, nil)
+	if err == nil {
+		t.Error("Error not reported for nonexistent revision")
+	} else if err.Error() != "Revision Not Found" {
+		t.Errorf(`Error mismatch: got %q, want "Revision Not Found"`, err)
+	}
+
+	if !gock.IsDone() {
+		t.Error("No endpoint called")
+	}
+}
@@ -24,13 +24,18 @@ import (
 	"github.com/BBVA/kapow/internal/http"
 )
 
-// AddRoute will add a new route in kapow
-func AddRoute(host, path, method, entrypoint, command string, w io.Writer) error {
+// AddRoute will add a new route in kapow.  The optional attributes of the
+// route, like its matchers, are given in attrs keyed by their JSON name.
+func AddRoute(host, path, method, entrypoint, command string, attrs map[string]interface{}, w io.Writer) error {
 	url := host + "/routes"
-	body, _ := json.Marshal(map[string]string{
-		"method":      method,
-		"url_pattern": path,
-		"entrypoint":  entrypoint,
-		"command":     command})
-	return http.Post(url, "application/json", bytes.NewReader(body), w)
+	route := map[string]interface{}{}
+	for k, v := range attrs {
+		route[k] = v
+	}
+	route["method"] = method
+	route["url_pattern"] = path
+	route["entrypoint"] = entrypoint
+	route["command"] = command
+	body, _ := json.Marshal(route)
+	return http.Post(url, "application/json", bytes.NewReader(body), w, withControlToken)
 }
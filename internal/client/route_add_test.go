@@ -39,7 +39,35 @@ func TestSuccessOnCorrectRoute(t *testing.T) {
 
 	err := AddRoute(
 		"http://localhost",
-		"/hello", "GET", "", "echo Hello World | kapow set /response/body", nil)
+		"/hello", "GET", "", "echo Hello World | kapow set /response/body", nil, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	if !gock.IsDone() {
+		t.Error("Expected endpoint call not made")
+	}
+}
+
+func TestAddRouteSendsTheGivenAttributes(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost").
+		Post("/routes").
+		MatchType("json").
+		JSON(map[string]interface{}{
+			"method":      "GET",
+			"url_pattern": "/hello",
+			"entrypoint":  "",
+			"command":     "echo Hello World | kapow set /response/body",
+			"host":        "api.example.com",
+		}).
+		Reply(http.StatusCreated).
+		JSON(map[string]string{})
+
+	err := AddRoute(
+		"http://localhost",
+		"/hello", "GET", "", "echo Hello World | kapow set /response/body",
+		map[string]interface{}{"host": "api.example.com"}, nil)
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err)
 	}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"io"
+
+	"github.com/BBVA/kapow/internal/http"
+)
+
+// EnableRoute makes a route of the Kapow! server serve requests again,
+// writing the resulting route to w
+func EnableRoute(host, id string, w io.Writer) error {
+	url := host + "/routes/" + id + "/enable"
+	return http.Post(url, "", nil, w, withControlToken)
+}
+
+// DisableRoute stops a route of the Kapow! server from serving requests,
+// keeping its id and position, and writes the resulting route to w
+func DisableRoute(host, id string, w io.Writer) error {
+	url := host + "/routes/" + id + "/disable"
+	return http.Post(url, "", nil, w, withControlToken)
+}
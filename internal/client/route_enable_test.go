@@ -0,0 +1,69 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestEnableRouteCallsTheEnableEndpoint(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Post("/routes/ROUTE_FOO/enable").
+		Reply(http.StatusOK)
+
+	if err := EnableRoute("http://localhost:8080", "ROUTE_FOO", nil); err != nil {
+		t.Errorf("unexpected error: %q", err)
+	}
+
+	if !gock.IsDone() {
+		t.Errorf("No endpoint called")
+	}
+}
+
+func TestDisableRouteCallsTheDisableEndpoint(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Post("/routes/ROUTE_FOO/disable").
+		Reply(http.StatusOK)
+
+	if err := DisableRoute("http://localhost:8080", "ROUTE_FOO", nil); err != nil {
+		t.Errorf("unexpected error: %q", err)
+	}
+
+	if !gock.IsDone() {
+		t.Errorf("No endpoint called")
+	}
+}
+
+func TestDisableRouteErrorNonExistent(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Post("/routes/ROUTE_BAD/disable").
+		Reply(http.StatusNotFound).
+		BodyString(`{"reason": "Route Not Found"}`)
+
+	err := DisableRoute("http://localhost:8080", "ROUTE_BAD", nil)
+	if err == nil {
+		t.Errorf("Error not reported for nonexistent route")
+	} else if err.Error() != "Route Not Found" {
+		t.Errorf(`Error mismatch: got %q, want "Route Not Found"`, err)
+	}
+}
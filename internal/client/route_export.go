@@ -0,0 +1,124 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/BBVA/kapow/internal/http"
+	"github.com/BBVA/kapow/internal/server/model"
+	usermux "github.com/BBVA/kapow/internal/server/user/mux"
+)
+
+// Import modes, deciding what to do with the routes already present in the
+// target Kapow! server
+const (
+	// ImportReplace removes every existing route before importing
+	ImportReplace = "replace"
+	// ImportMerge keeps the existing routes and imports all the given ones
+	ImportMerge = "merge"
+	// ImportSkip keeps the existing routes and imports only the given ones
+	// whose methods and url_pattern are not already in use
+	ImportSkip = "skip"
+)
+
+// ExportRoutes writes the routes of the Kapow! server to w as an indented
+// JSON list, suitable for ImportRoutes or as a route file.  With maskEnv the
+// values of the environment variables are hidden, so the result can be shared
+// but not imported as is.
+func ExportRoutes(host string, maskEnv bool, w io.Writer) error {
+	rs, err := getRoutes(host, maskEnv)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rs)
+}
+
+// ImportRoutes appends the given routes to the Kapow! server in the order
+// given by their index, and returns how many of them were imported.  The
+// whole import is applied at once, so either every route is imported or
+// none is.
+func ImportRoutes(host string, rs []model.Route, mode string) (int, error) {
+	if mode != ImportReplace && mode != ImportMerge && mode != ImportSkip {
+		return 0, fmt.Errorf("Invalid import mode %q", mode)
+	}
+
+	inUse := make(map[string]bool)
+	if mode == ImportSkip {
+		current, err := getRoutes(host, false)
+		if err != nil {
+			return 0, err
+		}
+		for _, r := range current {
+			inUse[routeKey(r)] = true
+		}
+	}
+
+	sorted := make([]model.Route, len(rs))
+	copy(sorted, rs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	ops := []model.Operation{}
+	if mode == ImportReplace {
+		ops = append(ops, model.Operation{Op: model.OpClear})
+	}
+	for i := range sorted {
+		if mode == ImportSkip && inUse[routeKey(sorted[i])] {
+			continue
+		}
+		sorted[i].ID = ""
+		ops = append(ops, model.Operation{Op: model.OpAppend, Route: &sorted[i]})
+	}
+
+	imported := len(ops)
+	if mode == ImportReplace {
+		imported--
+	}
+
+	if err := ApplyBatch(host, ops, nil); err != nil {
+		return 0, err
+	}
+
+	return imported, nil
+}
+
+// routeKey identifies the routes with the same methods and pattern, no matter
+// the case and order of the methods
+func routeKey(r model.Route) string {
+	return usermux.NormalizeMethod(r.Method) + " " + r.Pattern
+}
+
+// getRoutes retrieves the list of routes of the Kapow! server
+func getRoutes(host string, maskEnv bool) ([]model.Route, error) {
+	var buf bytes.Buffer
+	if err := http.Get(host+"/routes"+maskEnvQuery(maskEnv), "", nil, &buf, withControlToken); err != nil {
+		return nil, err
+	}
+
+	rs := []model.Route{}
+	if err := json.Unmarshal(buf.Bytes(), &rs); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	gock "gopkg.in/h2non/gock.v1"
+
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/routefile"
+)
+
+func TestExportRoutesAsksToMaskTheEnv(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Get("/routes").
+		MatchParam("mask_env", "true").
+		Reply(http.StatusOK).
+		BodyString(`[{"id":"FOO","env":{"TOKEN":"********"}}]`)
+
+	var b bytes.Buffer
+	if err := ExportRoutes("http://localhost:8080", true, &b); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !gock.IsDone() {
+		t.Error("No endpoint called")
+	}
+}
+
+func TestExportRoutesWritesAnIndentedList(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Get("/routes").
+		Reply(http.StatusOK).
+		BodyString(`[{"id":"FOO","method":"GET","url_pattern":"/foo","entrypoint":"","command":"","index":0}]`)
+
+	var b bytes.Buffer
+	if err := ExportRoutes("http://localhost:8080", false, &b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `[
+  {
+    "id": "FOO",
+    "method": "GET",
+    "url_pattern": "/foo",
+    "entrypoint": "",
+    "command": "",
+    "index": 0
+  }
+]
+`
+	if b.String() != expected {
+		t.Errorf("Output mismatch: got %q, want %q", b.String(), expected)
+	}
+}
+
+func TestImportRoutesRejectsUnknownModes(t *testing.T) {
+	if _, err := ImportRoutes("http://localhost:8080", nil, "FOO"); err == nil {
+		t.Error("Expected error not returned")
+	}
+}
+
+func TestImportRoutesAppendsInIndexOrder(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Post("/batch").
+		MatchType("json").
+		BodyString(`^\[{"op":"append","route":{[^}]*"url_pattern":"/first"[^}]*}},{"op":"append","route":{[^}]*"url_pattern":"/second"[^}]*}}\]$`).
+		Reply(http.StatusOK)
+
+	rs := []model.Route{
+		{ID: "B", Method: "GET", Pattern: "/second", Index: 1},
+		{ID: "A", Method: "GET", Pattern: "/first", Index: 0},
+	}
+	n, err := ImportRoutes("http://localhost:8080", rs, ImportMerge)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if n != 2 {
+		t.Errorf("Imported routes mismatch: got %d, want 2", n)
+	}
+	if !gock.IsDone() {
+		t.Error("Expected endpoint calls not made")
+	}
+}
+
+func TestImportRoutesKeepsTheOrderOfAnExportedFile(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Post("/batch").
+		MatchType("json").
+		BodyString(`"url_pattern":"/first".*"url_pattern":"/second"`).
+		Reply(http.StatusOK)
+
+	exported := `[
+  {"id": "B", "method": "GET", "url_pattern": "/second", "entrypoint": "", "command": "", "index": 1,
+   "auth": {"htpasswd_file": "/nonexistent/kapow/htpasswd"}},
+  {"id": "A", "method": "GET", "url_pattern": "/first", "entrypoint": "", "command": "", "index": 0}
+]`
+	rs, err := routefile.Decode("routes.json", []byte(exported))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := ImportRoutes("http://localhost:8080", rs, ImportMerge); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !gock.IsDone() {
+		t.Error("Expected endpoint calls not made")
+	}
+}
+
+func TestImportRoutesReplaceClearsExistingRoutes(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Post("/batch").
+		BodyString(`^\[{"op":"clear"},{"op":"append",`).
+		Reply(http.StatusOK)
+
+	rs := []model.Route{{Method: "GET", Pattern: "/new"}}
+	n, err := ImportRoutes("http://localhost:8080", rs, ImportReplace)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if n != 1 {
+		t.Errorf("Imported routes mismatch: got %d, want 1", n)
+	}
+	if !gock.IsDone() {
+		t.Error("Expected endpoint calls not made")
+	}
+}
+
+func TestImportRoutesSkipOmitsRoutesAlreadyInUse(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Get("/routes").
+		Reply(http.StatusOK).
+		BodyString(`[{"id":"OLD","method":"GET","url_pattern":"/old"}]`)
+	gock.New("http://localhost:8080").
+		Post("/batch").
+		BodyString(`^\[{"op":"append","route":{[^}]*"url_pattern":"/new"[^}]*}}\]$`).
+		Reply(http.StatusOK)
+
+	rs := []model.Route{
+		{Method: "GET", Pattern: "/old", Index: 0},
+		{Method: "GET", Pattern: "/new", Index: 1},
+	}
+	n, err := ImportRoutes("http://localhost:8080", rs, ImportSkip)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if n != 1 {
+		t.Errorf("Imported routes mismatch: got %d, want 1", n)
+	}
+	if !gock.IsDone() {
+		t.Error("Expected endpoint calls not made")
+	}
+}
+
+func TestImportRoutesSkipComparesTheMethodSets(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Get("/routes").
+		Reply(http.StatusOK).
+		BodyString(`[{"id":"OLD","method":"GET,HEAD","url_pattern":"/old"}]`)
+	gock.New("http://localhost:8080").
+		Post("/batch").
+		BodyString(`^\[{"op":"append","route":{[^}]*"url_pattern":"/new"[^}]*}}\]$`).
+		Reply(http.StatusOK)
+
+	rs := []model.Route{
+		{Method: "head, get", Pattern: "/old", Index: 0},
+		{Method: "GET", Pattern: "/new", Index: 1},
+	}
+	n, err := ImportRoutes("http://localhost:8080", rs, ImportSkip)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if n != 1 {
+		t.Errorf("Imported routes mismatch: got %d, want 1", n)
+	}
+	if !gock.IsDone() {
+		t.Error("Expected endpoint calls not made")
+	}
+}
+
+func TestImportRoutesPropagatesBatchErrors(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Post("/batch").
+		Reply(http.StatusUnprocessableEntity).
+		BodyString(`{"reason": "Invalid Route"}`)
+
+	rs := []model.Route{{Method: "GET", Pattern: "/he{{o"}}
+	n, err := ImportRoutes("http://localhost:8080", rs, ImportMerge)
+	if err == nil || err.Error() != "Invalid Route" {
+		t.Errorf(`Error mismatch: got %v, want "Invalid Route"`, err)
+	}
+
+	if n != 0 {
+		t.Errorf("Imported routes mismatch: got %d, want 0", n)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/BBVA/kapow/internal/http"
+)
+
+// InsertRoute will insert a new route in kapow at the given position.  The
+// optional attributes of the route are given in attrs, as in AddRoute.
+func InsertRoute(host, path, method, entrypoint, command string, index int, attrs map[string]interface{}, w io.Writer) error {
+	url := host + "/routes"
+	route := map[string]interface{}{}
+	for k, v := range attrs {
+		route[k] = v
+	}
+	route["method"] = method
+	route["url_pattern"] = path
+	route["entrypoint"] = entrypoint
+	route["command"] = command
+	route["index"] = index
+	body, _ := json.Marshal(route)
+	return http.Put(url, "application/json", bytes.NewReader(body), w, withControlToken)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestInsertRouteSendsIndex(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost").
+		Put("/routes").
+		MatchType("json").
+		JSON(map[string]interface{}{
+			"method":      "GET",
+			"url_pattern": "/hello",
+			"entrypoint":  "",
+			"command":     "echo Hello World | kapow set /response/body",
+			"index":       3,
+		}).
+		Reply(http.StatusCreated).
+		JSON(map[string]string{})
+
+	err := InsertRoute(
+		"http://localhost",
+		"/hello", "GET", "", "echo Hello World | kapow set /response/body", 3, nil, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	if !gock.IsDone() {
+		t.Error("Expected endpoint call not made")
+	}
+}
+
+func TestInsertRoutePropagatesUnprocessableEntity(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost").
+		Put("/routes").
+		Reply(http.StatusUnprocessableEntity).
+		BodyString(`{"reason": "Invalid Route"}`)
+
+	err := InsertRoute("http://localhost", "/hello", "GET", "", "", -1, nil, nil)
+	if err == nil {
+		t.Error("Expected error not returned")
+	} else if err.Error() != "Invalid Route" {
+		t.Errorf(`Error mismatch: got %q, want "Invalid Route"`, err)
+	}
+
+	if !gock.IsDone() {
+		t.Error("Expected endpoint call not made")
+	}
+}
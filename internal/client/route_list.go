@@ -18,12 +18,31 @@ package client
 
 import (
 	"io"
+	"net/url"
 
 	"github.com/BBVA/kapow/internal/http"
 )
 
 // ListRoutes queries the kapow! instance for the routes that are registered
 func ListRoutes(host string, w io.Writer) error {
+	return FindRoutes(host, nil, w)
+}
+
+// FindRoutes queries the kapow! instance for the registered routes that
+// comply with the given filters: selector, method and pattern_prefix
+func FindRoutes(host string, filters url.Values, w io.Writer) error {
 	url := host + "/routes"
-	return http.Get(url, "", nil, w)
+	if len(filters) > 0 {
+		url += "?" + filters.Encode()
+	}
+	return http.Get(url, "", nil, w, withControlToken)
+}
+
+// maskEnvQuery returns the query string asking the control API to hide the
+// values of the environment variables of the routes, if maskEnv is set
+func maskEnvQuery(maskEnv bool) string {
+	if maskEnv {
+		return "?mask_env=true"
+	}
+	return ""
 }
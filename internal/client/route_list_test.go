@@ -19,6 +19,7 @@ package client
 import (
 	"bytes"
 	"net/http"
+	"net/url"
 	"testing"
 
 	gock "gopkg.in/h2non/gock.v1"
@@ -64,3 +65,21 @@ func TestListRoutesOKSome(t *testing.T) {
 		t.Errorf("No endpoint called")
 	}
 }
+
+func TestFindRoutesSendsTheFilters(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Get("/routes").
+		MatchParam("selector", "^team=billing$").
+		MatchParam("method", "^GET$").
+		Reply(http.StatusOK)
+
+	err := FindRoutes("http://localhost:8080", url.Values{"selector": {"team=billing"}, "method": {"GET"}}, nil)
+	if err != nil {
+		t.Errorf("Unexpected error %q", err)
+	}
+
+	if !gock.IsDone() {
+		t.Errorf("No endpoint called")
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestMatchRouteSendsTheRequestDescription(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost:8080").
+		Post("/match").
+		MatchType("json").
+		JSON(map[string]interface{}{
+			"method":  "POST",
+			"url":     "/some/path",
+			"headers": map[string]string{"Host": "example.com"},
+		}).
+		Reply(http.StatusOK).
+		JSON(`{"route":null}`)
+
+	buf := new(bytes.Buffer)
+	if err := MatchRoute("http://localhost:8080", "POST", "/some/path", map[string]string{"Host": "example.com"}, buf); err != nil {
+		t.Errorf("unexpected error: %q", err)
+	}
+
+	if !gock.IsDone() {
+		t.Errorf("No endpoint called")
+	}
+	if buf.String() != `{"route":null}` {
+		t.Errorf(`Body mismatch: got %q, want "{\"route\":null}"`, buf)
+	}
+}
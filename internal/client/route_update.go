@@ -0,0 +1,33 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/BBVA/kapow/internal/http"
+)
+
+// UpdateRoute modifies in place the given fields of a registered route in
+// Kapow! server, keeping its id and position
+func UpdateRoute(host, id string, fields map[string]interface{}, w io.Writer) error {
+	url := host + "/routes/" + id
+	body, _ := json.Marshal(fields)
+	return http.Patch(url, "application/json", bytes.NewReader(body), w, withControlToken)
+}
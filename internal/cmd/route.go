@@ -17,11 +17,22 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/BBVA/kapow/internal/client"
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/routefile"
 
 	"github.com/spf13/cobra"
 )
@@ -37,13 +48,48 @@ func init() {
 		Short: "List the current Kapow! routes",
 		Run: func(cmd *cobra.Command, args []string) {
 			controlURL, _ := cmd.Flags().GetString("control-url")
+			output, _ := cmd.Flags().GetString("output")
 
-			if err := client.ListRoutes(controlURL, os.Stdout); err != nil {
-				log.Fatal(err)
+			filters := url.Values{}
+			for flag, param := range map[string]string{
+				"selector":       "selector",
+				"method":         "method",
+				"pattern-prefix": "pattern_prefix",
+			} {
+				if value, _ := cmd.Flags().GetString(flag); value != "" {
+					filters.Set(param, value)
+				}
+			}
+			if mask, _ := cmd.Flags().GetBool("mask-env"); mask {
+				filters.Set("mask_env", "true")
+			}
+
+			switch output {
+			case "json":
+				if err := client.FindRoutes(controlURL, filters, os.Stdout); err != nil {
+					log.Fatal(err)
+				}
+			case "table":
+				var buf bytes.Buffer
+				if err := client.FindRoutes(controlURL, filters, &buf); err != nil {
+					log.Fatal(err)
+				}
+				var rs []model.Route
+				if err := json.Unmarshal(buf.Bytes(), &rs); err != nil {
+					log.Fatal(err)
+				}
+				printRouteTable(os.Stdout, rs)
+			default:
+				log.Fatalf("Invalid output format %q, expected json or table", output)
 			}
 		},
 	}
 	routeListCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+	routeListCmd.Flags().StringP("selector", "l", "", "Label selector to filter routes, e.g. team=billing,env!=prod")
+	routeListCmd.Flags().StringP("method", "X", "", "Only list the routes accepting this HTTP method")
+	routeListCmd.Flags().String("pattern-prefix", "", "Only list the routes whose URL pattern starts with this prefix")
+	routeListCmd.Flags().StringP("output", "o", "json", "Output format: json or table")
+	routeListCmd.Flags().Bool("mask-env", false, "Hide the values of the environment variables of the routes")
 
 	// TODO: Manage args for url_pattern and command_file (2 exact args)
 	var routeAddCmd = &cobra.Command{
@@ -52,36 +98,244 @@ func init() {
 		Args:  cobra.RangeArgs(1, 2),
 		Run: func(cmd *cobra.Command, args []string) {
 			controlURL, _ := cmd.Flags().GetString("control-url")
-			method, _ := cmd.Flags().GetString("method")
+			methods, _ := cmd.Flags().GetStringSlice("method")
+			method := strings.Join(methods, ",")
 			command, _ := cmd.Flags().GetString("command")
 			entrypoint, _ := cmd.Flags().GetString("entrypoint")
 			urlPattern := args[0]
 
 			if len(args) > 1 && command == "" {
-				commandFile := args[1]
-				var buf []byte
-				var err error
-				if commandFile == "-" {
-					buf, err = ioutil.ReadAll(os.Stdin)
-				} else {
-					buf, err = ioutil.ReadFile(commandFile)
-				}
-				if err != nil {
-					log.Fatal(err)
-				}
-				command = string(buf)
+				command = readCommandFile(args[1])
 			}
 
-			if err := client.AddRoute(controlURL, urlPattern, method, entrypoint, command, os.Stdout); err != nil {
+			if err := client.AddRoute(controlURL, urlPattern, method, entrypoint, command, routeAttributes(cmd), os.Stdout); err != nil {
 				log.Fatal(err)
 			}
 		},
 	}
 	// TODO: Add default values for flags and remove path flag
 	routeAddCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
-	routeAddCmd.Flags().StringP("method", "X", "GET", "HTTP method to accept")
+	routeAddCmd.Flags().StringSliceP("method", "X", []string{"GET"}, "HTTP methods to accept, or * for any (can be repeated)")
 	routeAddCmd.Flags().StringP("entrypoint", "e", "/bin/sh -c", "Command to execute")
 	routeAddCmd.Flags().StringP("command", "c", "", "Command to pass to the shell")
+	addRouteAttributeFlags(routeAddCmd)
+
+	var routeInsertCmd = &cobra.Command{
+		Use:   "insert [flags] url_pattern [command_file]",
+		Short: "Insert a route at the given position",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			controlURL, _ := cmd.Flags().GetString("control-url")
+			methods, _ := cmd.Flags().GetStringSlice("method")
+			method := strings.Join(methods, ",")
+			command, _ := cmd.Flags().GetString("command")
+			entrypoint, _ := cmd.Flags().GetString("entrypoint")
+			index, _ := cmd.Flags().GetInt("index")
+			urlPattern := args[0]
+
+			if len(args) > 1 && command == "" {
+				command = readCommandFile(args[1])
+			}
+
+			if err := client.InsertRoute(controlURL, urlPattern, method, entrypoint, command, index, routeAttributes(cmd), os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	routeInsertCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+	routeInsertCmd.Flags().StringSliceP("method", "X", []string{"GET"}, "HTTP methods to accept, or * for any (can be repeated)")
+	routeInsertCmd.Flags().StringP("entrypoint", "e", "/bin/sh -c", "Command to execute")
+	routeInsertCmd.Flags().StringP("command", "c", "", "Command to pass to the shell")
+	routeInsertCmd.Flags().IntP("index", "i", 0, "Position of the route in the routes list")
+	addRouteAttributeFlags(routeInsertCmd)
+
+	var routeUpdateCmd = &cobra.Command{
+		Use:   "update [flags] route_id [command_file]",
+		Short: "Update the given route in place",
+		Long:  "Update the given fields of a route, keeping its id and position",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			controlURL, _ := cmd.Flags().GetString("control-url")
+			fields := map[string]interface{}{}
+
+			if cmd.Flags().Changed("method") {
+				methods, _ := cmd.Flags().GetStringSlice("method")
+				fields["method"] = strings.Join(methods, ",")
+			}
+			if cmd.Flags().Changed("url-pattern") {
+				fields["url_pattern"], _ = cmd.Flags().GetString("url-pattern")
+			}
+			if cmd.Flags().Changed("entrypoint") {
+				fields["entrypoint"], _ = cmd.Flags().GetString("entrypoint")
+			}
+			if cmd.Flags().Changed("command") {
+				fields["command"], _ = cmd.Flags().GetString("command")
+			} else if len(args) > 1 {
+				fields["command"] = readCommandFile(args[1])
+			}
+			for k, v := range routeAttributes(cmd) {
+				fields[k] = v
+			}
+
+			if err := client.UpdateRoute(controlURL, args[0], fields, os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	routeUpdateCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+	routeUpdateCmd.Flags().StringSliceP("method", "X", nil, "HTTP methods to accept, or * for any (can be repeated)")
+	routeUpdateCmd.Flags().StringP("url-pattern", "u", "", "URL pattern to match")
+	routeUpdateCmd.Flags().StringP("entrypoint", "e", "", "Command to execute")
+	routeUpdateCmd.Flags().StringP("command", "c", "", "Command to pass to the shell")
+	addRouteAttributeFlags(routeUpdateCmd)
+
+	var routeExportCmd = &cobra.Command{
+		Use:   "export [flags] [file]",
+		Short: "Export the current Kapow! routes",
+		Long:  "Export the current Kapow! routes to a file, or to the standard output",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			controlURL, _ := cmd.Flags().GetString("control-url")
+
+			maskEnv, _ := cmd.Flags().GetBool("mask-env")
+
+			var buf bytes.Buffer
+			if err := client.ExportRoutes(controlURL, maskEnv, &buf); err != nil {
+				log.Fatal(err)
+			}
+
+			if len(args) == 0 || args[0] == "-" {
+				_, _ = buf.WriteTo(os.Stdout)
+			} else if err := ioutil.WriteFile(args[0], buf.Bytes(), 0644); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	routeExportCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+	routeExportCmd.Flags().Bool("mask-env", false, "Hide the values of the environment variables of the routes")
+
+	var routeImportCmd = &cobra.Command{
+		Use:   "import [flags] file",
+		Short: "Import routes from a file",
+		Long:  "Import routes from an exported file or a route file, preserving their order",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			controlURL, _ := cmd.Flags().GetString("control-url")
+			mode, _ := cmd.Flags().GetString("mode")
+
+			// The target server validates the routes, as they may
+			// refer to files that only exist there
+			rs, err := routefile.Decode(args[0], []byte(readCommandFile(args[0])))
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			n, err := client.ImportRoutes(controlURL, rs, mode)
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("%d of %d routes imported\n", n, len(rs))
+		},
+	}
+	routeImportCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+	routeImportCmd.Flags().StringP("mode", "m", client.ImportMerge, "How to deal with the existing routes: replace, merge or skip")
+
+	var routeHistoryCmd = &cobra.Command{
+		Use:   "history [flags]",
+		Short: "List the revisions of the Kapow! route table",
+		Run: func(cmd *cobra.Command, args []string) {
+			controlURL, _ := cmd.Flags().GetString("control-url")
+
+			maskEnv, _ := cmd.Flags().GetBool("mask-env")
+
+			if err := client.ListRevisions(controlURL, maskEnv, os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	routeHistoryCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+	routeHistoryCmd.Flags().Bool("mask-env", false, "Hide the values of the environment variables of the routes")
+
+	var routeRollbackCmd = &cobra.Command{
+		Use:   "rollback [flags] revision",
+		Short: "Restore the route table to the given revision",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			controlURL, _ := cmd.Flags().GetString("control-url")
+
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				log.Fatalf("Invalid revision %q", args[0])
+			}
+
+			if err := client.RestoreRevision(controlURL, n, os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	routeRollbackCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+
+	var routeEnableCmd = &cobra.Command{
+		Use:   "enable [flags] route_id",
+		Short: "Make a disabled route serve requests again",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			controlURL, _ := cmd.Flags().GetString("control-url")
+
+			if err := client.EnableRoute(controlURL, args[0], os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	routeEnableCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+
+	var routeDisableCmd = &cobra.Command{
+		Use:   "disable [flags] route_id",
+		Short: "Stop a route from serving requests without removing it",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			controlURL, _ := cmd.Flags().GetString("control-url")
+
+			if err := client.DisableRoute(controlURL, args[0], os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	routeDisableCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+
+	var routeWatchCmd = &cobra.Command{
+		Use:   "watch [flags]",
+		Short: "Print the changes of the Kapow! route table as they happen",
+		Run: func(cmd *cobra.Command, args []string) {
+			controlURL, _ := cmd.Flags().GetString("control-url")
+
+			maskEnv, _ := cmd.Flags().GetBool("mask-env")
+
+			if err := client.WatchEvents(controlURL, maskEnv, os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	routeWatchCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+	routeWatchCmd.Flags().Bool("mask-env", false, "Hide the values of the environment variables of the routes")
+
+	var routeMatchCmd = &cobra.Command{
+		Use:   "match [flags] url",
+		Short: "Explain which route would handle the given request",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			controlURL, _ := cmd.Flags().GetString("control-url")
+			method, _ := cmd.Flags().GetString("method")
+			headers, _ := cmd.Flags().GetStringArray("header")
+
+			if err := client.MatchRoute(controlURL, method, args[0], parsePairs("header", headers), os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	routeMatchCmd.Flags().String("control-url", getEnv("KAPOW_CONTROL_URL", "http://localhost:8081"), "Kapow! control interface URL")
+	routeMatchCmd.Flags().StringP("method", "X", "GET", "HTTP method of the request")
+	routeMatchCmd.Flags().StringArrayP("header", "H", nil, "Header of the request, as NAME=VALUE (can be repeated)")
 
 	var routeRemoveCmd = &cobra.Command{
 		Use:   "remove [flags] route_id",
@@ -99,5 +353,179 @@ func init() {
 
 	RouteCmd.AddCommand(routeListCmd)
 	RouteCmd.AddCommand(routeAddCmd)
+	RouteCmd.AddCommand(routeInsertCmd)
+	RouteCmd.AddCommand(routeUpdateCmd)
 	RouteCmd.AddCommand(routeRemoveCmd)
+	RouteCmd.AddCommand(routeEnableCmd)
+	RouteCmd.AddCommand(routeDisableCmd)
+	RouteCmd.AddCommand(routeExportCmd)
+	RouteCmd.AddCommand(routeImportCmd)
+	RouteCmd.AddCommand(routeHistoryCmd)
+	RouteCmd.AddCommand(routeRollbackCmd)
+	RouteCmd.AddCommand(routeWatchCmd)
+	RouteCmd.AddCommand(routeMatchCmd)
+}
+
+// readCommandFile returns the contents of the given file, or of the standard
+// input if it is "-"
+func readCommandFile(commandFile string) string {
+	var buf []byte
+	var err error
+	if commandFile == "-" {
+		buf, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		buf, err = ioutil.ReadFile(commandFile)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(buf)
+}
+
+// addRouteAttributeFlags defines in cmd the flags of the optional route
+// attributes
+func addRouteAttributeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("host", "", "Host pattern the request must match")
+	cmd.Flags().StringArrayP("header", "H", nil, "Header the request must have, as NAME=REGEXP (can be repeated)")
+	cmd.Flags().StringArray("query", nil, "Query parameter the request must have, as NAME=PATTERN (can be repeated)")
+	cmd.Flags().StringSlice("scheme", nil, "URL schemes to accept (http, https)")
+	cmd.Flags().String("description", "", "Description of the route")
+	cmd.Flags().StringArray("label", nil, "Label of the route, as KEY=VALUE (can be repeated)")
+	cmd.Flags().StringArray("env", nil, "Environment variable for the entrypoint, as KEY=VALUE (can be repeated)")
+	cmd.Flags().String("workdir", "", "Working directory of the entrypoint")
+	cmd.Flags().Bool("tmpdir", false, "Give each request a private temporary directory, available in KAPOW_TMPDIR")
+	cmd.Flags().String("timeout", "", "Maximum execution time of the entrypoint, e.g. 30s (0 means no timeout)")
+	cmd.Flags().String("disconnect-signal", "", "Signal sent to the entrypoint when the client goes away, e.g. SIGTERM")
+	cmd.Flags().Int("max-concurrency", 0, "Maximum number of requests served at the same time (0 means no limit)")
+	cmd.Flags().Int("max-queue", 0, "Maximum number of requests waiting when max-concurrency is reached")
+	cmd.Flags().String("queue-timeout", "", "Maximum time a request waits for its turn, e.g. 10s")
+	cmd.Flags().Int("rate-limit", 0, "Number of requests allowed to each client every rate-per")
+	cmd.Flags().String("rate-per", "", "Period of rate-limit, e.g. 1m (default 1s)")
+	cmd.Flags().Int("rate-burst", 0, "Maximum number of requests a client can make at once (default rate-limit)")
+	cmd.Flags().String("rate-key", "", "How clients are told apart: ip (default) or header:NAME")
+	cmd.Flags().String("rate-trusted-header", "", "Header set by a trusted proxy with the client IP, e.g. X-Forwarded-For")
+	cmd.Flags().String("htpasswd-file", "", "htpasswd file with the users allowed to call the route (bcrypt, SHA-1 or SHA-256/512 crypt)")
+	cmd.Flags().String("auth-realm", "", "Realm announced to the clients asked for credentials (default \"kapow\")")
+	cmd.Flags().String("jwt-keys-file", "", "JWKS or PEM file with the keys that sign the accepted JWT bearer tokens")
+	cmd.Flags().String("jwt-issuer", "", "Issuer required in the JWT bearer tokens")
+	cmd.Flags().String("jwt-audience", "", "Audience required in the JWT bearer tokens")
+	cmd.Flags().StringArray("cert-dn", nil, "Pattern of the subject DN required in the client certificate (can be repeated)")
+	cmd.Flags().StringArray("cert-cn", nil, "Pattern of the subject CN required in the client certificate (can be repeated)")
+	cmd.Flags().StringArray("cert-ou", nil, "Pattern of a subject OU required in the client certificate (can be repeated)")
+	cmd.Flags().StringArray("cert-san", nil, "Pattern of a SAN required in the client certificate (can be repeated)")
+}
+
+// routeAttributes returns the optional route attributes given in the flags of
+// cmd, keyed by their JSON name.  Only the flags that were set are included.
+func routeAttributes(cmd *cobra.Command) map[string]interface{} {
+	attrs := map[string]interface{}{}
+
+	if cmd.Flags().Changed("host") {
+		attrs["host"], _ = cmd.Flags().GetString("host")
+	}
+	if cmd.Flags().Changed("header") {
+		headers, _ := cmd.Flags().GetStringArray("header")
+		attrs["headers"] = parsePairs("header", headers)
+	}
+	if cmd.Flags().Changed("query") {
+		queries, _ := cmd.Flags().GetStringArray("query")
+		attrs["queries"] = parsePairs("query", queries)
+	}
+	if cmd.Flags().Changed("scheme") {
+		attrs["schemes"], _ = cmd.Flags().GetStringSlice("scheme")
+	}
+	if cmd.Flags().Changed("description") {
+		attrs["description"], _ = cmd.Flags().GetString("description")
+	}
+	if cmd.Flags().Changed("label") {
+		labels, _ := cmd.Flags().GetStringArray("label")
+		attrs["labels"] = parsePairs("label", labels)
+	}
+	if cmd.Flags().Changed("env") {
+		env, _ := cmd.Flags().GetStringArray("env")
+		attrs["env"] = parsePairs("env", env)
+	}
+	if cmd.Flags().Changed("workdir") {
+		attrs["workdir"], _ = cmd.Flags().GetString("workdir")
+	}
+	if cmd.Flags().Changed("tmpdir") {
+		attrs["tmpdir"], _ = cmd.Flags().GetBool("tmpdir")
+	}
+	if cmd.Flags().Changed("timeout") {
+		attrs["timeout"], _ = cmd.Flags().GetString("timeout")
+	}
+	if cmd.Flags().Changed("disconnect-signal") {
+		attrs["disconnect_signal"], _ = cmd.Flags().GetString("disconnect-signal")
+	}
+	if cmd.Flags().Changed("max-concurrency") {
+		attrs["max_concurrency"], _ = cmd.Flags().GetInt("max-concurrency")
+	}
+	if cmd.Flags().Changed("max-queue") {
+		attrs["max_queue"], _ = cmd.Flags().GetInt("max-queue")
+	}
+	if cmd.Flags().Changed("queue-timeout") {
+		attrs["queue_timeout"], _ = cmd.Flags().GetString("queue-timeout")
+	}
+	if cmd.Flags().Changed("rate-limit") {
+		rl := map[string]interface{}{}
+		rl["requests"], _ = cmd.Flags().GetInt("rate-limit")
+		rl["per"], _ = cmd.Flags().GetString("rate-per")
+		rl["burst"], _ = cmd.Flags().GetInt("rate-burst")
+		rl["key"], _ = cmd.Flags().GetString("rate-key")
+		rl["trusted_header"], _ = cmd.Flags().GetString("rate-trusted-header")
+		attrs["rate_limit"] = rl
+	}
+	if cmd.Flags().Changed("htpasswd-file") {
+		a := map[string]interface{}{}
+		a["htpasswd_file"], _ = cmd.Flags().GetString("htpasswd-file")
+		a["realm"], _ = cmd.Flags().GetString("auth-realm")
+		attrs["auth"] = a
+	}
+	if cmd.Flags().Changed("jwt-keys-file") {
+		j := map[string]interface{}{}
+		j["keys_file"], _ = cmd.Flags().GetString("jwt-keys-file")
+		j["issuer"], _ = cmd.Flags().GetString("jwt-issuer")
+		j["audience"], _ = cmd.Flags().GetString("jwt-audience")
+		attrs["jwt"] = j
+	}
+	if cmd.Flags().Changed("cert-dn") || cmd.Flags().Changed("cert-cn") ||
+		cmd.Flags().Changed("cert-ou") || cmd.Flags().Changed("cert-san") {
+		c := map[string]interface{}{}
+		c["dn"], _ = cmd.Flags().GetStringArray("cert-dn")
+		c["cn"], _ = cmd.Flags().GetStringArray("cert-cn")
+		c["ou"], _ = cmd.Flags().GetStringArray("cert-ou")
+		c["san"], _ = cmd.Flags().GetStringArray("cert-san")
+		attrs["client_cert"] = c
+	}
+
+	return attrs
+}
+
+// parsePairs turns a list of NAME=VALUE strings given in flag into a map
+func parsePairs(flag string, pairs []string) map[string]string {
+	m := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			log.Fatalf("Invalid %s %q, expected NAME=VALUE", flag, p)
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m
+}
+
+// printRouteTable writes rs to w as a human readable table
+func printRouteTable(w io.Writer, rs []model.Route) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "INDEX\tID\tMETHOD\tURL_PATTERN\tENABLED\tLABELS\tDESCRIPTION")
+	for _, r := range rs {
+		labels := make([]string, 0, len(r.Labels))
+		for k, v := range r.Labels {
+			labels = append(labels, k+"="+v)
+		}
+		sort.Strings(labels)
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%t\t%s\t%s\n",
+			r.Index, r.ID, r.Method, r.Pattern, r.IsEnabled(), strings.Join(labels, ","), r.Description)
+	}
+	tw.Flush()
 }
@@ -27,11 +27,13 @@ import (
 
 	"github.com/BBVA/kapow/internal/logger"
 	"github.com/BBVA/kapow/internal/server"
+	"github.com/BBVA/kapow/internal/server/control"
+	"github.com/BBVA/kapow/internal/server/routefile"
 )
 
 // ServerCmd is the command line interface for kapow server
 var ServerCmd = &cobra.Command{
-	Use:   "server [optional flags] [optional pow file(s)]",
+	Use:   "server [optional flags] [optional pow or route file(s)]",
 	Short: "Start a kapow server",
 	Long: `Start a Kapow server with a client interface, a data interface	and an
 	admin interface`,
@@ -46,15 +48,45 @@ var ServerCmd = &cobra.Command{
 		sConf.KeyFile, _ = cmd.Flags().GetString("keyfile")
 
 		sConf.ClientAuth, _ = cmd.Flags().GetBool("clientauth")
+		sConf.ClientAuthOptional, _ = cmd.Flags().GetBool("clientauth-optional")
 		sConf.ClientCaFile, _ = cmd.Flags().GetString("clientcafile")
+
+		sConf.ControlCertFile, _ = cmd.Flags().GetString("control-certfile")
+		sConf.ControlKeyFile, _ = cmd.Flags().GetString("control-keyfile")
+		sConf.ControlClientAuth, _ = cmd.Flags().GetBool("control-clientauth")
+		sConf.ControlClientCaFile, _ = cmd.Flags().GetString("control-clientcafile")
+
+		sConf.DataCertFile, _ = cmd.Flags().GetString("data-certfile")
+		sConf.DataKeyFile, _ = cmd.Flags().GetString("data-keyfile")
+		sConf.DataClientAuth, _ = cmd.Flags().GetBool("data-clientauth")
+		sConf.DataClientCaFile, _ = cmd.Flags().GetString("data-clientcafile")
+
+		sConf.StateFile, _ = cmd.Flags().GetString("state-file")
+		sConf.RespondDisabled, _ = cmd.Flags().GetBool("respond-disabled")
+		sConf.StrictRoutes, _ = cmd.Flags().GetBool("strict-routes")
+		sConf.Timeout, _ = cmd.Flags().GetDuration("timeout")
+
+		if tokenFile, _ := cmd.Flags().GetString("control-token-file"); tokenFile != "" {
+			tokens, err := control.ReadTokenFile(tokenFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			sConf.ControlTokens = tokens
+		}
+		if token := os.Getenv("KAPOW_CONTROL_TOKEN"); token != "" {
+			sConf.ControlTokens = append(sConf.ControlTokens, token)
+		}
+		// The commands serving the user routes inherit the server
+		// environment, so they must not get the control credentials
+		os.Unsetenv("KAPOW_CONTROL_TOKEN")
 		debug, _ := cmd.Flags().GetBool("debug")
 
 		// Set environment variables KAPOW_DATA_URL and KAPOW_CONTROL_URL only if they aren't set so we don't overwrite user's preferences
 		if _, exist := os.LookupEnv("KAPOW_DATA_URL"); !exist {
-			os.Setenv("KAPOW_DATA_URL", "http://"+sConf.DataBindAddr)
+			os.Setenv("KAPOW_DATA_URL", scheme(sConf.DataCertFile)+"://"+sConf.DataBindAddr)
 		}
 		if _, exist := os.LookupEnv("KAPOW_CONTROL_URL"); !exist {
-			os.Setenv("KAPOW_CONTROL_URL", "http://"+sConf.ControlBindAddr)
+			os.Setenv("KAPOW_CONTROL_URL", scheme(sConf.ControlCertFile)+"://"+sConf.ControlBindAddr)
 		}
 
 		if debug {
@@ -69,18 +101,29 @@ var ServerCmd = &cobra.Command{
 			if os.IsNotExist(err) {
 				log.Fatalf("%s does not exist", powfile)
 			}
-			log.Printf("Running powfile: %q\n", powfile)
-			kapowCMD := exec.Command("bash", powfile)
-			kapowCMD.Stdout = os.Stdout
-			kapowCMD.Stderr = os.Stderr
-			kapowCMD.Env = os.Environ()
-
-			err = kapowCMD.Run()
-			if err != nil {
-				log.Fatal(err)
+			if routefile.IsRouteFile(powfile) {
+				if err = server.LoadRouteFile(powfile); err != nil {
+					log.Fatal(err)
+				}
+				log.Printf("Routes loaded from route file: %q\n", powfile)
+			} else {
+				log.Printf("Running powfile: %q\n", powfile)
+				kapowCMD := exec.Command("bash", powfile)
+				kapowCMD.Stdout = os.Stdout
+				kapowCMD.Stderr = os.Stderr
+				kapowCMD.Env = os.Environ()
+				// Let the pow file authenticate against the control server
+				if len(sConf.ControlTokens) > 0 {
+					kapowCMD.Env = append(kapowCMD.Env, "KAPOW_CONTROL_TOKEN="+sConf.ControlTokens[0])
+				}
+
+				err = kapowCMD.Run()
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println()
+				log.Printf("Done running powfile: %q\n", powfile)
 			}
-			fmt.Println()
-			log.Printf("Done running powfile: %q\n", powfile)
 		}
 
 		if debug {
@@ -101,29 +144,63 @@ func init() {
 
 	ServerCmd.Flags().Bool("clientauth", false, "Activate client mutual tls authentication")
 	ServerCmd.Flags().String("clientcafile", "", "Cert file to validate client certificates")
+	ServerCmd.Flags().Bool("clientauth-optional", false, "Verify client certificates only when given, so routes can require them or not")
+
+	ServerCmd.Flags().String("control-certfile", "", "Cert file to serve the control interface thru https")
+	ServerCmd.Flags().String("control-keyfile", "", "Key file to serve the control interface thru https")
+	ServerCmd.Flags().Bool("control-clientauth", false, "Activate client mutual tls authentication in the control interface")
+	ServerCmd.Flags().String("control-clientcafile", "", "Cert file to validate control interface client certificates")
+
+	ServerCmd.Flags().String("data-certfile", "", "Cert file to serve the data interface thru https")
+	ServerCmd.Flags().String("data-keyfile", "", "Key file to serve the data interface thru https")
+	ServerCmd.Flags().Bool("data-clientauth", false, "Activate client mutual tls authentication in the data interface")
+	ServerCmd.Flags().String("data-clientcafile", "", "Cert file to validate data interface client certificates")
+
+	ServerCmd.Flags().String("state-file", "", "File where routes are persisted across restarts")
+	ServerCmd.Flags().Bool("respond-disabled", false, "Answer the requests to disabled routes with 503 instead of skipping them")
+	ServerCmd.Flags().Bool("strict-routes", false, "Reject route changes leaving two routes with the same method, URL pattern and matchers")
+	ServerCmd.Flags().Duration("timeout", 0, "Default execution timeout of the routes, e.g. 30s (0 means no timeout)")
+	ServerCmd.Flags().String("control-token-file", "", "File with the bearer tokens accepted by the control interface, one per line")
 
 	ServerCmd.Flags().Bool("debug", false, "Activate debug mode for script executions to standard output")
 }
 
 func validateServerCommandArguments(cmd *cobra.Command, args []string) error {
-	cert, _ := cmd.Flags().GetString("certfile")
-	key, _ := cmd.Flags().GetString("keyfile")
-	cliAuth, _ := cmd.Flags().GetBool("clientauth")
+	// Each interface has its own set of TLS flags, with the given prefix
+	for _, prefix := range []string{"", "control-", "data-"} {
+		cert, _ := cmd.Flags().GetString(prefix + "certfile")
+		key, _ := cmd.Flags().GetString(prefix + "keyfile")
+		cliAuth, _ := cmd.Flags().GetBool(prefix + "clientauth")
+
+		if (cert == "") != (key == "") {
+			return fmt.Errorf("expected both or neither (%scertfile and %skeyfile)", prefix, prefix)
+		}
 
-	if (cert == "") != (key == "") {
-		return errors.New("expected both or neither (certfile and keyfile)")
+		if cert == "" {
+			// If we don't serve thru https client authentication can't be enabled
+			if cliAuth {
+				return fmt.Errorf("Client authentication can't be active in a non https server (%sclientauth)", prefix)
+			}
+		}
 	}
 
-	if cert == "" {
-		// If we don't serve thru https client authentication can't be enabled
-		if cliAuth {
-			return errors.New("Client authentication can't be active in a non https server")
-		}
+	optional, _ := cmd.Flags().GetBool("clientauth-optional")
+	if cliAuth, _ := cmd.Flags().GetBool("clientauth"); optional && !cliAuth {
+		return errors.New("clientauth-optional requires clientauth")
 	}
 
 	return nil
 }
 
+// scheme returns the URL scheme of an interface served with the given cert
+// file
+func scheme(certFile string) string {
+	if certFile != "" {
+		return "https"
+	}
+	return "http"
+}
+
 func processLogs() {
 
 	for {
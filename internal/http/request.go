@@ -24,23 +24,28 @@ import (
 )
 
 // Get perform a request using Request with the GET method
-func Get(url string, contentType string, r io.Reader, w io.Writer) error {
-	return Request("GET", url, contentType, r, w)
+func Get(url string, contentType string, r io.Reader, w io.Writer, reqTuners ...func(*http.Request)) error {
+	return Request("GET", url, contentType, r, w, reqTuners...)
 }
 
 // Post perform a request using Request with the POST method
-func Post(url string, contentType string, r io.Reader, w io.Writer) error {
-	return Request("POST", url, contentType, r, w)
+func Post(url string, contentType string, r io.Reader, w io.Writer, reqTuners ...func(*http.Request)) error {
+	return Request("POST", url, contentType, r, w, reqTuners...)
 }
 
 // Put perform a request using Request with the PUT method
-func Put(url string, contentType string, r io.Reader, w io.Writer) error {
-	return Request("PUT", url, contentType, r, w)
+func Put(url string, contentType string, r io.Reader, w io.Writer, reqTuners ...func(*http.Request)) error {
+	return Request("PUT", url, contentType, r, w, reqTuners...)
+}
+
+// Patch perform a request using Request with the PATCH method
+func Patch(url string, contentType string, r io.Reader, w io.Writer, reqTuners ...func(*http.Request)) error {
+	return Request("PATCH", url, contentType, r, w, reqTuners...)
 }
 
 // Delete perform a request using Request with the DELETE method
-func Delete(url string, contentType string, r io.Reader, w io.Writer) error {
-	return Request("DELETE", url, contentType, r, w)
+func Delete(url string, contentType string, r io.Reader, w io.Writer, reqTuners ...func(*http.Request)) error {
+	return Request("DELETE", url, contentType, r, w, reqTuners...)
 }
 
 var devnull = ioutil.Discard
@@ -48,8 +53,9 @@ var devnull = ioutil.Discard
 // Request will perform the request to the given url and method sending the
 // content of the given reader as the body and writing all the contents
 // of the response to the given writer. The reader and writer are
-// optional.
-func Request(method string, url string, contentType string, r io.Reader, w io.Writer) error {
+// optional. The request can be further customized, e.g. adding headers, by
+// the given reqTuners before being sent.
+func Request(method string, url string, contentType string, r io.Reader, w io.Writer, reqTuners ...func(*http.Request)) error {
 	req, err := http.NewRequest(method, url, r)
 	if err != nil {
 		return err
@@ -59,7 +65,16 @@ func Request(method string, url string, contentType string, r io.Reader, w io.Wr
 		req.Header.Add("Content-Type", contentType)
 	}
 
-	res, err := new(http.Client).Do(req)
+	for _, tuner := range reqTuners {
+		tuner(req)
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -152,6 +152,24 @@ func TestSendContentType(t *testing.T) {
 	}
 }
 
+func TestRequestAppliesTheGivenTuners(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost").
+		MatchHeader("X-Foo", "bar").
+		Reply(http.StatusOK)
+
+	err := Request("GET", "http://localhost", "", nil, nil, func(req *http.Request) {
+		req.Header.Set("X-Foo", "bar")
+	})
+	if err != nil {
+		t.Errorf("Unexpected error '%v'", err.Error())
+	}
+
+	if !gock.IsDone() {
+		t.Error("No expected endpoint called")
+	}
+}
+
 func TestGetRequestsWithMethodGet(t *testing.T) {
 	defer gock.Off()
 	gock.New("http://localhost").
@@ -203,6 +221,23 @@ func TestPutRequestsWithMethodPut(t *testing.T) {
 	}
 }
 
+func TestPatchRequestsWithMethodPatch(t *testing.T) {
+	defer gock.Off()
+	gock.New("http://localhost").
+		Patch("/").
+		Reply(http.StatusOK)
+
+	err := Patch("http://localhost/", "", nil, nil)
+
+	if err != nil {
+		t.Errorf("Unexpected error %q", err)
+	}
+
+	if !gock.IsDone() {
+		t.Error("No expected endpoint called")
+	}
+}
+
 func TestDeleteRequestsWithMethodDelete(t *testing.T) {
 	defer gock.Off()
 	gock.New("http://localhost").
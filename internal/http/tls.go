@@ -0,0 +1,70 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+// newClient returns the http.Client used by Request.  When KAPOW_CACERT is
+// set, the server certificate is validated against the CAs in that PEM file
+// instead of the system store.  When KAPOW_CLIENT_CERT is set, the client
+// presents that certificate, with the private key found in KAPOW_CLIENT_KEY
+// or, if unset, in the certificate file itself.
+func newClient() (*http.Client, error) {
+	caFile := os.Getenv("KAPOW_CACERT")
+	certFile := os.Getenv("KAPOW_CLIENT_CERT")
+	keyFile := os.Getenv("KAPOW_CLIENT_KEY")
+
+	if caFile == "" && certFile == "" {
+		return new(http.Client), nil
+	}
+
+	config := &tls.Config{}
+	if caFile != "" {
+		caCerts, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(caCerts) {
+			return nil, fmt.Errorf("Invalid certificate file %s", caFile)
+		}
+	}
+	if certFile != "" {
+		if keyFile == "" {
+			keyFile = certFile
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: config,
+		},
+	}, nil
+}
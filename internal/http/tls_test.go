@@ -0,0 +1,115 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// writePEM stores the given PEM blocks in a temporary file and returns its
+// path
+func writePEM(t *testing.T, blocks ...*pem.Block) string {
+	f, err := ioutil.TempFile("", "kapow-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, b := range blocks {
+		if err := pem.Encode(f, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f.Name()
+}
+
+// serverCertPEM returns the certificate of the given test server as a PEM
+// block
+func serverCertPEM(ts *httptest.Server) *pem.Block {
+	return &pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}
+}
+
+func TestRequestFailsAgainstUntrustedServer(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+	os.Unsetenv("KAPOW_CACERT")
+
+	if err := Get(ts.URL, "", nil, nil); err == nil {
+		t.Error("Expected error not returned")
+	}
+}
+
+func TestRequestTrustsCAFromEnvironment(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+	caFile := writePEM(t, serverCertPEM(ts))
+	defer os.Remove(caFile)
+	os.Setenv("KAPOW_CACERT", caFile)
+	defer os.Unsetenv("KAPOW_CACERT")
+
+	if err := Get(ts.URL, "", nil, nil); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRequestFailsOnInvalidCAFile(t *testing.T) {
+	caFile := writePEM(t)
+	defer os.Remove(caFile)
+	os.Setenv("KAPOW_CACERT", caFile)
+	defer os.Unsetenv("KAPOW_CACERT")
+
+	if err := Get("https://localhost", "", nil, nil); err == nil {
+		t.Error("Expected error not returned")
+	}
+}
+
+func TestRequestPresentsClientCertificateFromEnvironment(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	// The test server certificate is self signed, so it is used as the
+	// client certificate too
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(ts.TLS.Certificates[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := writePEM(t, serverCertPEM(ts))
+	defer os.Remove(caFile)
+	certFile := writePEM(t, serverCertPEM(ts), &pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})
+	defer os.Remove(certFile)
+	os.Setenv("KAPOW_CACERT", caFile)
+	defer os.Unsetenv("KAPOW_CACERT")
+
+	if err := Get(ts.URL, "", nil, nil); err == nil {
+		t.Error("Expected error not returned without client certificate")
+	}
+
+	os.Setenv("KAPOW_CLIENT_CERT", certFile)
+	defer os.Unsetenv("KAPOW_CLIENT_CERT")
+
+	if err := Get(ts.URL, "", nil, nil); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
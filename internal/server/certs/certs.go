@@ -0,0 +1,69 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package certs holds the TLS configuration helpers shared by the Kapow!
+// servers.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// LoadCertificatesFromFile returns a pool with the PEM certificates found in
+// certFile, or a nil pool, meaning the system store, if certFile is empty.
+func LoadCertificatesFromFile(certFile string) (pool *x509.CertPool, err error) {
+	if certFile != "" {
+		var caCerts []byte
+		caCerts, err = ioutil.ReadFile(certFile)
+		if err == nil {
+			pool = x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caCerts) {
+				err = fmt.Errorf("Invalid certificate file %s", certFile)
+			}
+		}
+	}
+
+	return
+}
+
+// ClientAuthConfig returns a TLS configuration verifying the client
+// certificates against the CAs in cliCaFile, or in the system store if
+// cliCaFile is empty.  clientAuth tells whether the certificates are required
+// (tls.RequireAndVerifyClientCert) or only verified when given
+// (tls.VerifyClientCertIfGiven).
+func ClientAuthConfig(cliCaFile string, clientAuth tls.ClientAuthType) (*tls.Config, error) {
+	pool, err := LoadCertificatesFromFile(cliCaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: clientAuth,
+	}, nil
+}
+
+// CAStoreName returns a description of the CA store used to validate client
+// certificates, suitable for logging.
+func CAStoreName(cliCaFile string) string {
+	if cliCaFile != "" {
+		return cliCaFile
+	}
+	return "System store"
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certs
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLoadCertificatesFromFileReturnsNilPoolWithoutFile(t *testing.T) {
+	pool, err := LoadCertificatesFromFile("")
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if pool != nil {
+		t.Error("Expected nil pool for the system store")
+	}
+}
+
+func TestLoadCertificatesFromFileLoadsPEMFile(t *testing.T) {
+	pool, err := LoadCertificatesFromFile("testdata/ca.crt")
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if pool == nil {
+		t.Error("Expected a pool")
+	}
+}
+
+func TestLoadCertificatesFromFileFailsOnInvalidFile(t *testing.T) {
+	f, _ := ioutil.TempFile("", "ca")
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("not a certificate")
+	f.Close()
+
+	if _, err := LoadCertificatesFromFile(f.Name()); err == nil {
+		t.Error("Expected error not returned")
+	}
+}
+
+func TestClientAuthConfigRequiresClientCertificates(t *testing.T) {
+	cfg, err := ClientAuthConfig("testdata/ca.crt", tls.RequireAndVerifyClientCert)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("Unexpected client auth type: %v", cfg.ClientAuth)
+	}
+	if cfg.ClientCAs == nil {
+		t.Error("Client CAs not set")
+	}
+}
+
+func TestClientAuthConfigCanVerifyOnlyGivenCertificates(t *testing.T) {
+	cfg, err := ClientAuthConfig("testdata/ca.crt", tls.VerifyClientCertIfGiven)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Errorf("Unexpected client auth type: %v", cfg.ClientAuth)
+	}
+}
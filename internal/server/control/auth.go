@@ -0,0 +1,93 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/BBVA/kapow/internal/server/httperror"
+)
+
+// authTokens holds the bearer tokens accepted by the control server.  No
+// authentication is required when empty.
+var authTokens []string
+
+// ReadTokenFile returns the tokens found in the given file, one per line.
+// Blank lines and lines starting with # are ignored.
+func ReadTokenFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read token file: %v", err)
+	}
+	defer f.Close()
+
+	tokens := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to read token file: %v", err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("No tokens found in token file %s", path)
+	}
+
+	return tokens, nil
+}
+
+// authenticate is a middleware that rejects with 401 every request not
+// carrying one of the authTokens as its bearer token
+func authenticate(next http.Handler) http.Handler {
+	if len(authTokens) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if !validToken(req.Header.Get("Authorization")) {
+			res.Header().Set("WWW-Authenticate", `Bearer realm="kapow"`)
+			httperror.ErrorJSON(res, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(res, req)
+	})
+}
+
+// validToken checks the given Authorization header against all the
+// authTokens in constant time
+func validToken(header string) bool {
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return false
+	}
+	token := []byte(header[len(prefix):])
+
+	valid := 0
+	for _, t := range authTokens {
+		valid |= subtle.ConstantTimeCompare(token, []byte(t))
+	}
+	return valid == 1
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestReadTokenFileSkipsBlankAndCommentLines(t *testing.T) {
+	f, _ := ioutil.TempFile("", "tokens")
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("# ops team\nfoo\n\n  bar  \n")
+	f.Close()
+
+	tokens, err := ReadTokenFile(f.Name())
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(tokens, []string{"foo", "bar"}) {
+		t.Errorf("Unexpected tokens: %v", tokens)
+	}
+}
+
+func TestReadTokenFileFailsWhenNoTokens(t *testing.T) {
+	f, _ := ioutil.TempFile("", "tokens")
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("# nothing here\n")
+	f.Close()
+
+	if _, err := ReadTokenFile(f.Name()); err == nil {
+		t.Error("Expected error not returned")
+	}
+}
+
+func TestReadTokenFileFailsWhenMissing(t *testing.T) {
+	if _, err := ReadTokenFile("/does/not/exist"); err == nil {
+		t.Error("Expected error not returned")
+	}
+}
+
+func TestAuthenticateLetsEverythingPassWithoutTokens(t *testing.T) {
+	authTokens = nil
+	called := false
+	handler := authenticate(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/routes", nil))
+
+	if !called {
+		t.Error("Handler not called")
+	}
+}
+
+func TestAuthenticateRejectsMissingToken(t *testing.T) {
+	authTokens = []string{"s3cr3t"}
+	defer func() { authTokens = nil }()
+	called := false
+	handler := authenticate(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/routes", nil))
+
+	if called {
+		t.Error("Handler unexpectedly called")
+	}
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnauthorized, "Unauthorized") {
+		t.Error(e)
+	}
+	if resp.Header().Get("WWW-Authenticate") == "" {
+		t.Error("WWW-Authenticate header not set")
+	}
+}
+
+func TestAuthenticateRejectsWrongToken(t *testing.T) {
+	authTokens = []string{"s3cr3t"}
+	defer func() { authTokens = nil }()
+	handler := authenticate(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/routes", nil)
+	req.Header.Set("Authorization", "Bearer s3cr3tx")
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnauthorized, "Unauthorized") {
+		t.Error(e)
+	}
+}
+
+func TestAuthenticateAcceptsAnyConfiguredToken(t *testing.T) {
+	authTokens = []string{"foo", "s3cr3t"}
+	defer func() { authTokens = nil }()
+	called := false
+	handler := authenticate(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+	req := httptest.NewRequest(http.MethodGet, "/routes", nil)
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !called {
+		t.Error("Handler not called")
+	}
+}
+
+func TestConfigRouterAuthenticatesRequests(t *testing.T) {
+	authTokens = []string{"s3cr3t"}
+	defer func() { authTokens = nil }()
+	resp := httptest.NewRecorder()
+
+	configRouter().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/routes", nil))
+
+	if resp.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP status mismatch. Expected: %d, got: %d", http.StatusUnauthorized, resp.Code)
+	}
+}
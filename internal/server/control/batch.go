@@ -0,0 +1,145 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/BBVA/kapow/internal/server/httperror"
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user"
+)
+
+// errRouteNotFound is returned when an operation refers to a nonexistent
+// route
+var errRouteNotFound = errors.New("Route Not Found")
+
+// funcTransaction Method used to ask the route model module to replace the
+// whole list of routes at once
+var funcTransaction func(func([]model.Route) ([]model.Route, error)) ([]model.Route, error) = user.Routes.Transaction
+
+// batchRoutes Handler that applies a list of operations to the route table
+// as a whole. Every operation is validated before applying any of them, and
+// if any of them fails the route table is left untouched. With StrictRoutes,
+// the batches leaving two routes with the same methods, pattern and matchers
+// are rejected
+func batchRoutes(res http.ResponseWriter, req *http.Request) {
+	var ops []model.Operation
+
+	payload, _ := ioutil.ReadAll(req.Body)
+	if err := json.Unmarshal(payload, &ops); err != nil {
+		httperror.ErrorJSON(res, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	for i := range ops {
+		switch ops[i].Op {
+		case model.OpClear, model.OpDelete, model.OpAppend, model.OpInsert, model.OpUpdate:
+		default:
+			httperror.ErrorJSON(res, "Invalid Operation", http.StatusUnprocessableEntity)
+			return
+		}
+
+		if (ops[i].Op == model.OpUpdate || ops[i].Op == model.OpDelete) && ops[i].ID == "" {
+			httperror.ErrorJSON(res, "Invalid Operation", http.StatusUnprocessableEntity)
+			return
+		}
+
+		if ops[i].Op == model.OpAppend || ops[i].Op == model.OpInsert || ops[i].Op == model.OpUpdate {
+			if ops[i].Route == nil || ops[i].Route.Index < 0 || !validRoute(ops[i].Route) {
+				httperror.ErrorJSON(res, "Invalid Route", http.StatusUnprocessableEntity)
+				return
+			}
+		}
+
+		if ops[i].Op == model.OpAppend || ops[i].Op == model.OpInsert {
+			id, err := idGenerator()
+			if err != nil {
+				httperror.ErrorJSON(res, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			ops[i].Route.ID = id.String()
+		}
+	}
+
+	rs, err := funcTransaction(func(rs []model.Route) ([]model.Route, error) {
+		for _, op := range ops {
+			if StrictRoutes && introducesDuplicate(rs, op) {
+				return nil, errDuplicatedRoute
+			}
+			var err error
+			if rs, err = applyOperation(rs, op); err != nil {
+				return nil, err
+			}
+		}
+		return rs, nil
+	})
+	switch err {
+	case nil:
+	case errRouteNotFound:
+		httperror.ErrorJSON(res, "Route Not Found", http.StatusNotFound)
+		return
+	case errDuplicatedRoute:
+		httperror.ErrorJSON(res, "Duplicated Route", http.StatusConflict)
+		return
+	case user.ErrPersist:
+		httperror.ErrorJSON(res, "Unable to Persist Routes", http.StatusInternalServerError)
+		return
+	default:
+		httperror.ErrorJSON(res, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	rsBytes, _ := json.Marshal(maskRoutes(req, rs))
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(rsBytes)
+}
+
+// applyOperation returns the result of applying a single, already validated,
+// operation to the given list of routes
+func applyOperation(rs []model.Route, op model.Operation) ([]model.Route, error) {
+	switch op.Op {
+	case model.OpClear:
+		return []model.Route{}, nil
+	case model.OpAppend:
+		return append(rs, *op.Route), nil
+	case model.OpInsert:
+		i := op.Route.Index
+		if i > len(rs) {
+			i = len(rs)
+		}
+		rs = append(rs, model.Route{})
+		copy(rs[i+1:], rs[i:])
+		rs[i] = *op.Route
+		return rs, nil
+	}
+
+	for i := range rs {
+		if rs[i].ID == op.ID {
+			if op.Op == model.OpDelete {
+				return append(rs[:i], rs[i+1:]...), nil
+			}
+			rs[i] = *op.Route
+			rs[i].ID = op.ID
+			return rs, nil
+		}
+	}
+	return nil, errRouteNotFound
+}
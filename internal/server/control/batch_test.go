@@ -0,0 +1,190 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user"
+)
+
+func TestBatchRoutesReturnsBadRequestWhenMalformedJSONBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`[{"op": `))
+	resp := httptest.NewRecorder()
+
+	batchRoutes(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusBadRequest, "Malformed JSON") {
+		t.Error(e)
+	}
+}
+
+func TestBatchRoutesChangesNothingWhenAnyRouteIsInvalid(t *testing.T) {
+	reqPayload := `[
+	{"op": "clear"},
+	{"op": "append", "route": {"method": "GET", "url_pattern": "/hello"}},
+	{"op": "append", "route": {"method": "GET", "url_pattern": "/he{{o"}}
+]`
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	called := false
+	funcTransaction = func(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+		called = true
+		return fn([]model.Route{})
+	}
+
+	batchRoutes(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnprocessableEntity, "Invalid Route") {
+		t.Error(e)
+	}
+	if called {
+		t.Error("Route table modified with an invalid batch")
+	}
+}
+
+func TestBatchRoutes422sWhenUnknownOperation(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`[{"op": "explode"}]`))
+	resp := httptest.NewRecorder()
+
+	batchRoutes(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnprocessableEntity, "Invalid Operation") {
+		t.Error(e)
+	}
+}
+
+func TestBatchRoutes422sWhenUpdateOrDeleteHasNoID(t *testing.T) {
+	for _, payload := range []string{
+		`[{"op": "delete"}]`,
+		`[{"op": "update", "id": "", "route": {"method": "GET", "url_pattern": "/hello"}}]`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(payload))
+		resp := httptest.NewRecorder()
+		called := false
+		funcTransaction = func(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+			called = true
+			return fn([]model.Route{{ID: "FOO"}})
+		}
+
+		batchRoutes(resp, req)
+
+		for _, e := range checkErrorResponse(resp.Result(), http.StatusUnprocessableEntity, "Invalid Operation") {
+			t.Errorf("%s: %s", payload, e)
+		}
+		if called {
+			t.Errorf("%s: Route table modified with an invalid batch", payload)
+		}
+	}
+}
+
+func TestBatchRoutes500sWhenTheChangeCannotBePersisted(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`[{"op": "clear"}]`))
+	resp := httptest.NewRecorder()
+	funcTransaction = func(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+		return nil, user.ErrPersist
+	}
+
+	batchRoutes(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusInternalServerError, "Unable to Persist Routes") {
+		t.Error(e)
+	}
+}
+
+func TestBatchRoutesWhenStrict409sWhenLeavingDuplicates(t *testing.T) {
+	payload := `[
+		{"op": "append", "route": {"method": "GET,HEAD", "url_pattern": "/hello"}},
+		{"op": "append", "route": {"method": "HEAD,GET", "url_pattern": "/hello"}}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(payload))
+	resp := httptest.NewRecorder()
+	defer func() { StrictRoutes = false }()
+	funcTransaction = func(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+		return fn([]model.Route{})
+	}
+	StrictRoutes = true
+
+	batchRoutes(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusConflict, "Duplicated Route") {
+		t.Error(e)
+	}
+}
+
+func TestBatchRoutes404sWhenRouteDoesntExist(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`[{"op": "delete", "id": "BAR"}]`))
+	resp := httptest.NewRecorder()
+	funcTransaction = func(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+		return fn([]model.Route{{ID: "FOO"}})
+	}
+
+	batchRoutes(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusNotFound, "Route Not Found") {
+		t.Error(e)
+	}
+}
+
+func TestBatchRoutesAppliesEveryOperationInOrder(t *testing.T) {
+	reqPayload := `[
+	{"op": "delete", "id": "FOO"},
+	{"op": "update", "id": "BAR", "route": {"method": "POST", "url_pattern": "/bar"}},
+	{"op": "append", "route": {"method": "GET", "url_pattern": "/qux"}},
+	{"op": "insert", "route": {"method": "GET", "url_pattern": "/baz", "index": 0}}
+]`
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	funcTransaction = func(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+		return fn([]model.Route{
+			{ID: "FOO", Method: "GET", Pattern: "/foo"},
+			{ID: "BAR", Method: "GET", Pattern: "/bar"},
+		})
+	}
+
+	batchRoutes(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("HTTP status mismatch. Expected: %d, got: %d", http.StatusOK, resp.Code)
+	}
+
+	var rs []model.Route
+	if err := json.Unmarshal(resp.Body.Bytes(), &rs); err != nil {
+		t.Fatalf("Invalid JSON response. %s", resp.Body.String())
+	}
+
+	patterns := []string{}
+	for _, r := range rs {
+		patterns = append(patterns, r.Method+" "+r.Pattern)
+	}
+	expected := []string{"GET /baz", "POST /bar", "GET /qux"}
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Errorf("Resulting routes mismatch. Expected: %v, got: %v", expected, patterns)
+	}
+	if rs[1].ID != "BAR" {
+		t.Errorf("Updated route lost its ID: %q", rs[1].ID)
+	}
+	if rs[0].ID == "" || rs[2].ID == "" {
+		t.Error("IDs not generated for the new routes")
+	}
+}
@@ -18,8 +18,10 @@ package control
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -27,21 +29,47 @@ import (
 	"github.com/BBVA/kapow/internal/server/httperror"
 	"github.com/BBVA/kapow/internal/server/model"
 	"github.com/BBVA/kapow/internal/server/user"
+	usermux "github.com/BBVA/kapow/internal/server/user/mux"
 )
 
 // configRouter Populates the server mux with all the supported routes. The
-// server exposes list, get, delete and add route endpoints.
+// server exposes list, get, delete, add, insert, update, enable and disable
+// route endpoints, along with a batch endpoint to apply several changes at
+// once, the revision history endpoints, a stream of route table events and an
+// endpoint explaining which route handles a request. When authTokens are
+// configured every request must be authenticated with one of them.
 func configRouter() *mux.Router {
 	r := mux.NewRouter()
+	r.Use(authenticate)
 
 	r.HandleFunc("/routes/{id}", removeRoute).
 		Methods(http.MethodDelete)
 	r.HandleFunc("/routes/{id}", getRoute).
 		Methods(http.MethodGet)
+	r.HandleFunc("/routes/{id}", replaceRoute).
+		Methods(http.MethodPut)
+	r.HandleFunc("/routes/{id}", patchRoute).
+		Methods(http.MethodPatch)
+	r.HandleFunc("/routes/{id}/enable", enableRoute).
+		Methods(http.MethodPost)
+	r.HandleFunc("/routes/{id}/disable", disableRoute).
+		Methods(http.MethodPost)
 	r.HandleFunc("/routes", listRoutes).
 		Methods(http.MethodGet)
 	r.HandleFunc("/routes", addRoute).
 		Methods(http.MethodPost)
+	r.HandleFunc("/routes", insertRoute).
+		Methods(http.MethodPut)
+	r.HandleFunc("/batch", batchRoutes).
+		Methods(http.MethodPost)
+	r.HandleFunc("/revisions", listRevisions).
+		Methods(http.MethodGet)
+	r.HandleFunc("/revisions/{n}/restore", restoreRevision).
+		Methods(http.MethodPost)
+	r.HandleFunc("/events", watchEvents).
+		Methods(http.MethodGet)
+	r.HandleFunc("/match", matchRoute).
+		Methods(http.MethodPost)
 	r.NotFoundHandler = http.HandlerFunc(defNotFoundHandler)
 	r.MethodNotAllowedHandler = http.HandlerFunc(defMethodNotAllowedHandler)
 
@@ -64,7 +92,10 @@ var funcRemove func(id string) error = user.Routes.Delete
 func removeRoute(res http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 	id := vars["id"]
-	if err := funcRemove(id); err != nil {
+	if err := funcRemove(id); err == user.ErrPersist {
+		httperror.ErrorJSON(res, "Unable to Persist Routes", http.StatusInternalServerError)
+		return
+	} else if err != nil {
 		httperror.ErrorJSON(res, "Route Not Found", http.StatusNotFound)
 		return
 	}
@@ -75,31 +106,101 @@ func removeRoute(res http.ResponseWriter, req *http.Request) {
 // funcList Method used to ask the route model module for the list of routes
 var funcList func() []model.Route = user.Routes.List
 
-// listRoutes Handler that retrieves a list of the existing routes. An empty
-// list is returned when no routes exist
+// listRoutes Handler that retrieves a list of the existing routes, optionally
+// filtered by the selector, method and pattern_prefix query parameters. An
+// empty list is returned when no routes exist
 func listRoutes(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	filter, err := routeFilter(query)
+	if err != nil {
+		httperror.ErrorJSON(res, "Invalid Selector", http.StatusBadRequest)
+		return
+	}
 
-	list := funcList()
+	list := []model.Route{}
+	for _, r := range funcList() {
+		if filter(r) {
+			list = append(list, r)
+		}
+	}
+	list = maskRoutes(req, list)
 
 	listBytes, _ := json.Marshal(list)
 	res.Header().Set("Content-Type", "application/json")
 	_, _ = res.Write(listBytes)
 }
 
+// maskedValue replaces the values of the masked environment variables
+const maskedValue = "********"
+
+// maskRequested Tells whether req asks, with the mask_env query parameter, to
+// hide the values of the environment variables of the routes in the response.
+// Every endpoint returning routes honours it
+func maskRequested(req *http.Request) bool {
+	mask, _ := strconv.ParseBool(req.URL.Query().Get("mask_env"))
+	return mask
+}
+
+// maskRoute Returns r with its environment masked if req asks for it
+func maskRoute(req *http.Request, r model.Route) model.Route {
+	if maskRequested(req) {
+		return maskEnv(r)
+	}
+	return r
+}
+
+// maskRoutes Returns rs with their environment masked if req asks for it,
+// leaving rs untouched
+func maskRoutes(req *http.Request, rs []model.Route) []model.Route {
+	if rs == nil || !maskRequested(req) {
+		return rs
+	}
+	masked := make([]model.Route, len(rs))
+	for i, r := range rs {
+		masked[i] = maskEnv(r)
+	}
+	return masked
+}
+
+// maskEnv Returns a copy of r with the values of its environment variables
+// replaced by maskedValue
+func maskEnv(r model.Route) model.Route {
+	if len(r.Env) == 0 {
+		return r
+	}
+	env := make(map[string]string, len(r.Env))
+	for k := range r.Env {
+		env[k] = maskedValue
+	}
+	r.Env = env
+	return r
+}
+
 // funcAdd Method used to ask the route model module to append a new route
-var funcAdd func(model.Route) model.Route = user.Routes.Append
+var funcAdd func(model.Route) (model.Route, error) = user.Routes.Append
 
 // idGenerator UUID generator for new routes
 var idGenerator = uuid.NewUUID
 
-// pathValidator Validates that a path complies with the gorilla mux
-// requirements
-var pathValidator func(string) error = func(path string) error {
-	return mux.NewRouter().NewRoute().BuildOnly().Path(path).GetError()
+// routeValidator Validates that a route can be added to the route table
+var routeValidator func(model.Route) error = usermux.ValidateRoute
+
+// validRoute Checks that the mandatory fields of a route are present, that
+// its pattern and matchers comply with the gorilla mux requirements and that
+// its execution settings, limits and authentication can be applied.  The
+// method of a valid route is normalized, so the stored routes always show the
+// set of methods they actually match
+func validRoute(route *model.Route) bool {
+	if routeValidator(*route) != nil {
+		return false
+	}
+	route.Method = usermux.NormalizeMethod(route.Method)
+	return true
 }
 
 // addRoute Handler that adds a new route. Makes all parameter validation and
-// creates the a new is for the route
+// creates the a new is for the route. With StrictRoutes, a route with the same
+// methods and pattern as an existing one is rejected
 func addRoute(res http.ResponseWriter, req *http.Request) {
 	var route model.Route
 
@@ -110,18 +211,47 @@ func addRoute(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if route.Method == "" {
+	if !validRoute(&route) {
 		httperror.ErrorJSON(res, "Invalid Route", http.StatusUnprocessableEntity)
 		return
 	}
 
-	if route.Pattern == "" {
-		httperror.ErrorJSON(res, "Invalid Route", http.StatusUnprocessableEntity)
+	id, err := idGenerator()
+	if err != nil {
+		httperror.ErrorJSON(res, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
-	err = pathValidator(route.Pattern)
+	route.ID = id.String()
+
+	var created model.Route
+	if StrictRoutes {
+		created, err = addUnique(route, model.OpAppend)
+	} else {
+		created, err = funcAdd(route)
+	}
+	writeCreated(res, req, created, err)
+}
+
+// funcInsert Method used to ask the route model module to insert a new route
+// at a given position
+var funcInsert func(model.Route) (model.Route, error) = user.Routes.Insert
+
+// insertRoute Handler that inserts a new route at the position given by its
+// index field. Indexes beyond the end of the list are clamped to the last
+// position and negative ones are rejected. With StrictRoutes, a route with the
+// same methods, pattern and matchers as an existing one is rejected
+func insertRoute(res http.ResponseWriter, req *http.Request) {
+	var route model.Route
+
+	payload, _ := ioutil.ReadAll(req.Body)
+	err := json.Unmarshal(payload, &route)
 	if err != nil {
+		httperror.ErrorJSON(res, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	if route.Index < 0 || !validRoute(&route) {
 		httperror.ErrorJSON(res, "Invalid Route", http.StatusUnprocessableEntity)
 		return
 	}
@@ -134,8 +264,63 @@ func addRoute(res http.ResponseWriter, req *http.Request) {
 
 	route.ID = id.String()
 
-	created := funcAdd(route)
-	createdBytes, _ := json.Marshal(created)
+	var created model.Route
+	if StrictRoutes {
+		created, err = addUnique(route, model.OpInsert)
+	} else {
+		created, err = funcInsert(route)
+	}
+	writeCreated(res, req, created, err)
+}
+
+// errDuplicatedRoute is returned when a route has the same methods, pattern and
+// matchers as an existing one
+var errDuplicatedRoute = errors.New("Duplicated Route")
+
+// addUnique Appends or inserts route, as told by op, unless the route table
+// already has a duplicate of it.  Both the check and the change are done in
+// the same transaction, so concurrent requests can't add duplicates
+func addUnique(route model.Route, op string) (model.Route, error) {
+	rs, err := funcTransaction(func(rs []model.Route) ([]model.Route, error) {
+		operation := model.Operation{Op: op, Route: &route}
+		if introducesDuplicate(rs, operation) {
+			return nil, errDuplicatedRoute
+		}
+		return applyOperation(rs, operation)
+	})
+	if err != nil {
+		return model.Route{}, err
+	}
+	for _, r := range rs {
+		if r.ID == route.ID {
+			return r, nil
+		}
+	}
+	return route, nil
+}
+
+// createdRoute is the response to the creation of a route, along with the
+// warnings about its reachability
+type createdRoute struct {
+	model.Route
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// writeCreated Writes the created route to the response, warning about the
+// routes it shadows or is shadowed by, or the error that prevented its creation
+func writeCreated(res http.ResponseWriter, req *http.Request, created model.Route, err error) {
+	if err == errDuplicatedRoute {
+		httperror.ErrorJSON(res, "Duplicated Route", http.StatusConflict)
+		return
+	} else if err != nil {
+		httperror.ErrorJSON(res, "Unable to Persist Routes", http.StatusInternalServerError)
+		return
+	}
+
+	createdBytes, _ := json.Marshal(createdRoute{
+		Route:    maskRoute(req, created),
+		Warnings: shadowWarnings(funcList(), created.ID),
+	})
 
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusCreated)
@@ -153,7 +338,147 @@ func getRoute(res http.ResponseWriter, req *http.Request) {
 		httperror.ErrorJSON(res, "Route Not Found", http.StatusNotFound)
 	} else {
 		res.Header().Set("Content-Type", "application/json")
-		rBytes, _ := json.Marshal(r)
+		rBytes, _ := json.Marshal(maskRoute(req, r))
 		_, _ = res.Write(rBytes)
 	}
 }
+
+// errInvalidRoute is returned by the update functions when the resulting route
+// doesn't pass validation
+var errInvalidRoute = errors.New("Invalid Route")
+
+// funcUpdate Method used to ask the route model module to atomically modify
+// a route, keeping its id and position
+var funcUpdate func(string, func(*model.Route) error) (model.Route, error) = user.Routes.Update
+
+// replaceRoute Handler that replaces every field of the route identified by
+// id with the provided ones. The id and the index of the route are kept
+func replaceRoute(res http.ResponseWriter, req *http.Request) {
+	var route model.Route
+
+	payload, _ := ioutil.ReadAll(req.Body)
+	if err := json.Unmarshal(payload, &route); err != nil {
+		httperror.ErrorJSON(res, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	if !validRoute(&route) {
+		httperror.ErrorJSON(res, "Invalid Route", http.StatusUnprocessableEntity)
+		return
+	}
+
+	updateRoute(res, req, func(r *model.Route) error {
+		*r = route
+		return nil
+	})
+}
+
+// patchRoute Handler that modifies only the fields of the route identified by
+// id that are present in the payload. Each field present replaces the current
+// value as a whole. The id and the index of the route are kept
+func patchRoute(res http.ResponseWriter, req *http.Request) {
+	var fields map[string]json.RawMessage
+
+	payload, _ := ioutil.ReadAll(req.Body)
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		httperror.ErrorJSON(res, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	updateRoute(res, req, func(r *model.Route) error {
+		// Decoding onto a fresh route keeps the maps and lists of the
+		// current one untouched, as they are shared with the history
+		current, _ := json.Marshal(r)
+		merged := map[string]json.RawMessage{}
+		_ = json.Unmarshal(current, &merged)
+		for k, v := range fields {
+			merged[k] = v
+		}
+		mergedBytes, _ := json.Marshal(merged)
+
+		var patched model.Route
+		if err := json.Unmarshal(mergedBytes, &patched); err != nil || !validRoute(&patched) {
+			return errInvalidRoute
+		}
+		*r = patched
+		return nil
+	})
+}
+
+// enableRoute Handler that makes the route identified by id serve requests
+// again. If it doesn't exist, returns 404 and an error entity
+func enableRoute(res http.ResponseWriter, req *http.Request) {
+	setEnabled(res, req, true)
+}
+
+// disableRoute Handler that stops the route identified by id from serving
+// requests, keeping its id and index. If it doesn't exist, returns 404 and an
+// error entity
+func disableRoute(res http.ResponseWriter, req *http.Request) {
+	setEnabled(res, req, false)
+}
+
+// setEnabled Sets the enabled flag of the route identified by the id in the
+// path of req
+func setEnabled(res http.ResponseWriter, req *http.Request, enabled bool) {
+	updateRoute(res, req, func(r *model.Route) error {
+		r.Enabled = &enabled
+		return nil
+	})
+}
+
+// updateRoute Performs the update of the route identified by the id in the
+// path of req through funcUpdate, or updateUnique with StrictRoutes, and
+// writes the resulting route or the appropriate error to the response
+func updateRoute(res http.ResponseWriter, req *http.Request, fn func(*model.Route) error) {
+	update := funcUpdate
+	if StrictRoutes {
+		update = updateUnique
+	}
+	updated, err := update(mux.Vars(req)["id"], fn)
+	if err == errInvalidRoute {
+		httperror.ErrorJSON(res, "Invalid Route", http.StatusUnprocessableEntity)
+		return
+	} else if err == errDuplicatedRoute {
+		httperror.ErrorJSON(res, "Duplicated Route", http.StatusConflict)
+		return
+	} else if err == user.ErrPersist {
+		httperror.ErrorJSON(res, "Unable to Persist Routes", http.StatusInternalServerError)
+		return
+	} else if err != nil {
+		httperror.ErrorJSON(res, "Route Not Found", http.StatusNotFound)
+		return
+	}
+
+	updatedBytes, _ := json.Marshal(maskRoute(req, updated))
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(updatedBytes)
+}
+
+// updateUnique Applies fn to the route identified by id, like funcUpdate, unless
+// the result would duplicate another route.  Both the check and the change are
+// done in the same transaction, so concurrent requests can't add duplicates
+func updateUnique(id string, fn func(*model.Route) error) (model.Route, error) {
+	var updated model.Route
+	_, err := funcTransaction(func(rs []model.Route) ([]model.Route, error) {
+		for i := range rs {
+			if rs[i].ID != id {
+				continue
+			}
+			r := rs[i]
+			if err := fn(&r); err != nil {
+				return nil, err
+			}
+			if introducesDuplicate(rs, model.Operation{Op: model.OpUpdate, ID: id, Route: &r}) {
+				return nil, errDuplicatedRoute
+			}
+			r.ID = id
+			r.Index = i
+			rs[i] = r
+			updated = r
+			return rs, nil
+		}
+		return nil, user.ErrRouteNotFound
+	})
+	return updated, err
+}
@@ -66,13 +66,22 @@ func TestConfigRouterHasRoutesWellConfigured(t *testing.T) {
 		vars            []string
 	}{
 		{"/routes/FOO", http.MethodGet, reflect.ValueOf(getRoute).Pointer(), true, []string{"id"}},
-		{"/routes/FOO", http.MethodPut, reflect.ValueOf(defMethodNotAllowedHandler).Pointer(), true, []string{}},
+		{"/routes/FOO", http.MethodPut, reflect.ValueOf(replaceRoute).Pointer(), true, []string{"id"}},
+		{"/routes/FOO", http.MethodPatch, reflect.ValueOf(patchRoute).Pointer(), true, []string{"id"}},
 		{"/routes/FOO", http.MethodPost, reflect.ValueOf(defMethodNotAllowedHandler).Pointer(), true, []string{}},
 		{"/routes/FOO", http.MethodDelete, reflect.ValueOf(removeRoute).Pointer(), true, []string{"id"}},
 		{"/routes", http.MethodGet, reflect.ValueOf(listRoutes).Pointer(), true, []string{}},
-		{"/routes", http.MethodPut, reflect.ValueOf(defMethodNotAllowedHandler).Pointer(), true, []string{}},
+		{"/routes", http.MethodPut, reflect.ValueOf(insertRoute).Pointer(), true, []string{}},
 		{"/routes", http.MethodPost, reflect.ValueOf(addRoute).Pointer(), true, []string{}},
 		{"/routes", http.MethodDelete, reflect.ValueOf(defMethodNotAllowedHandler).Pointer(), true, []string{}},
+		{"/batch", http.MethodPost, reflect.ValueOf(batchRoutes).Pointer(), true, []string{}},
+		{"/batch", http.MethodGet, reflect.ValueOf(defMethodNotAllowedHandler).Pointer(), true, []string{}},
+		{"/revisions", http.MethodGet, reflect.ValueOf(listRevisions).Pointer(), true, []string{}},
+		{"/revisions/1/restore", http.MethodPost, reflect.ValueOf(restoreRevision).Pointer(), true, []string{"n"}},
+		{"/events", http.MethodGet, reflect.ValueOf(watchEvents).Pointer(), true, []string{}},
+		{"/match", http.MethodPost, reflect.ValueOf(matchRoute).Pointer(), true, []string{}},
+		{"/routes/FOO/enable", http.MethodPost, reflect.ValueOf(enableRoute).Pointer(), true, []string{"id"}},
+		{"/routes/FOO/disable", http.MethodPost, reflect.ValueOf(disableRoute).Pointer(), true, []string{"id"}},
 		{"/", http.MethodGet, reflect.ValueOf(defNotFoundHandler).Pointer(), true, []string{}},
 		{"/", http.MethodPut, reflect.ValueOf(defNotFoundHandler).Pointer(), true, []string{}},
 		{"/", http.MethodPost, reflect.ValueOf(defNotFoundHandler).Pointer(), true, []string{}},
@@ -108,16 +117,16 @@ func TestConfigRouterHasRoutesWellConfigured(t *testing.T) {
 	}
 }
 
-func TestPathValidatorNoErrorWhenCorrectPath(t *testing.T) {
-	err := pathValidator("/routes/{routeID}")
+func TestRouteValidatorNoErrorWhenCorrectPath(t *testing.T) {
+	err := routeValidator(model.Route{Method: "GET", Pattern: "/routes/{routeID}"})
 
 	if err != nil {
 		t.Error(err)
 	}
 }
 
-func TestPathValidatorErrorWhenInvalidPath(t *testing.T) {
-	err := pathValidator("/routes/{routeID{")
+func TestRouteValidatorErrorWhenInvalidPath(t *testing.T) {
+	err := routeValidator(model.Route{Method: "GET", Pattern: "/routes/{routeID{"})
 
 	if err == nil {
 		t.FailNow()
@@ -226,14 +235,14 @@ func TestAddRouteGeneratesRouteID(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
 	resp := httptest.NewRecorder()
 	var genID string
-	funcAdd = func(input model.Route) model.Route {
+	funcAdd = func(input model.Route) (model.Route, error) {
 		genID = input.ID
 		input.Index = 0
-		return input
+		return input, nil
 	}
-	origPathValidator := pathValidator
-	defer func() { pathValidator = origPathValidator }()
-	pathValidator = func(path string) error { return nil }
+	origRouteValidator := routeValidator
+	defer func() { routeValidator = origRouteValidator }()
+	routeValidator = func(route model.Route) error { return nil }
 
 	addRoute(resp, req)
 
@@ -252,9 +261,9 @@ func TestAddRoute500sWhenIDGeneratorFails(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
 	resp := httptest.NewRecorder()
 
-	origPathValidator := pathValidator
-	defer func() { pathValidator = origPathValidator }()
-	pathValidator = func(path string) error { return nil }
+	origRouteValidator := routeValidator
+	defer func() { routeValidator = origRouteValidator }()
+	routeValidator = func(route model.Route) error { return nil }
 
 	idGenOrig := idGenerator
 	defer func() { idGenerator = idGenOrig }()
@@ -270,6 +279,21 @@ func TestAddRoute500sWhenIDGeneratorFails(t *testing.T) {
 	}
 }
 
+func TestAddRouteReturns500WhenTheRouteCannotBePersisted(t *testing.T) {
+	reqPayload := `{"method": "GET", "url_pattern": "/hello", "command": "echo"}`
+	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	funcAdd = func(input model.Route) (model.Route, error) {
+		return model.Route{}, user.ErrPersist
+	}
+
+	addRoute(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusInternalServerError, "Unable to Persist Routes") {
+		t.Error(e)
+	}
+}
+
 func TestAddRouteReturnsCreated(t *testing.T) {
 	reqPayload := `{
 	"method": "GET",
@@ -281,19 +305,19 @@ func TestAddRouteReturnsCreated(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
 	resp := httptest.NewRecorder()
 	var genID string
-	funcAdd = func(input model.Route) model.Route {
+	funcAdd = func(input model.Route) (model.Route, error) {
 		expected := model.Route{ID: input.ID, Method: "GET", Pattern: "/hello", Entrypoint: "/bin/sh -c", Command: "echo Hello World | kapow set /response/body"}
-		if input == expected {
+		if reflect.DeepEqual(input, expected) {
 			genID = input.ID
 			input.Index = 0
-			return input
+			return input, nil
 		}
 
-		return model.Route{}
+		return model.Route{}, nil
 	}
-	origPathValidator := pathValidator
-	defer func() { pathValidator = origPathValidator }()
-	pathValidator = func(path string) error { return nil }
+	origRouteValidator := routeValidator
+	defer func() { routeValidator = origRouteValidator }()
+	routeValidator = func(route model.Route) error { return nil }
 
 	addRoute(resp, req)
 
@@ -311,7 +335,7 @@ func TestAddRouteReturnsCreated(t *testing.T) {
 	}
 
 	expectedRouteSpec := model.Route{Method: "GET", Pattern: "/hello", Entrypoint: "/bin/sh -c", Command: "echo Hello World | kapow set /response/body", Index: 0, ID: genID}
-	if respJson != expectedRouteSpec {
+	if !reflect.DeepEqual(respJson, expectedRouteSpec) {
 		t.Errorf("Response mismatch. Expected %#v, got: %#v", expectedRouteSpec, respJson)
 	}
 }
@@ -325,15 +349,532 @@ func TestAddRoute422sWhenInvalidRoute(t *testing.T) {
 }`
 	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
 	resp := httptest.NewRecorder()
-	origPathValidator := pathValidator
-	defer func() { pathValidator = origPathValidator }()
-	pathValidator = func(path string) error { return errors.New("Invalid route") }
+	origRouteValidator := routeValidator
+	defer func() { routeValidator = origRouteValidator }()
+	routeValidator = func(route model.Route) error { return errors.New("Invalid route") }
+
+	addRoute(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnprocessableEntity, "Invalid Route") {
+		t.Error(e)
+	}
+}
+
+func TestAddRoute422sWhenInvalidMatchers(t *testing.T) {
+	reqPayload := `{
+	"method": "GET",
+	"url_pattern": "/hello",
+	"schemes": ["ftp"],
+	"entrypoint": "/bin/sh -c",
+	"command": "echo Hello World | kapow set /response/body"
+}`
+	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	addRoute(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnprocessableEntity, "Invalid Route") {
+		t.Error(e)
+	}
+}
+
+func TestAddRouteKeepsTheMatchers(t *testing.T) {
+	reqPayload := `{
+	"method": "POST",
+	"url_pattern": "/hello",
+	"host": "api.example.com",
+	"headers": {"Content-Type": "^application/json"},
+	"queries": {"id": "{id:[0-9]+}"},
+	"schemes": ["https"],
+	"entrypoint": "/bin/sh -c",
+	"command": "echo Hello World | kapow set /response/body"
+}`
+	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	var added model.Route
+	origAdd := funcAdd
+	defer func() { funcAdd = origAdd }()
+	funcAdd = func(input model.Route) (model.Route, error) {
+		added = input
+		return input, nil
+	}
 
 	addRoute(resp, req)
 
+	if resp.Code != http.StatusCreated {
+		t.Errorf("HTTP status mismatch. Expected: %d, got: %d", http.StatusCreated, resp.Code)
+	}
+	if added.Host != "api.example.com" ||
+		!reflect.DeepEqual(added.Headers, map[string]string{"Content-Type": "^application/json"}) ||
+		!reflect.DeepEqual(added.Queries, map[string]string{"id": "{id:[0-9]+}"}) ||
+		!reflect.DeepEqual(added.Schemes, []string{"https"}) {
+		t.Errorf("Matchers not kept: %#v", added)
+	}
+}
+
+func TestAddRouteNormalizesTheMethod(t *testing.T) {
+	reqPayload := `{"method": "post, get,GET", "url_pattern": "/hello", "command": "echo"}`
+	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	var added model.Route
+	origAdd := funcAdd
+	defer func() { funcAdd = origAdd }()
+	funcAdd = func(input model.Route) (model.Route, error) {
+		added = input
+		return input, nil
+	}
+
+	addRoute(resp, req)
+
+	if added.Method != "GET,POST" {
+		t.Errorf("Method not normalized. Expected: %q, got: %q", "GET,POST", added.Method)
+	}
+}
+
+func TestAddRoute422sWhenTheMethodListHasEmptyEntries(t *testing.T) {
+	reqPayload := `{"method": "GET,,POST", "url_pattern": "/hello", "command": "echo"}`
+	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+
+	addRoute(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnprocessableEntity, "Invalid Route") {
+		t.Error(e)
+	}
+}
+
+func TestAddRouteWarnsWhenTheRouteIsShadowed(t *testing.T) {
+	reqPayload := `{"method": "GET", "url_pattern": "/hello/world", "command": "echo"}`
+	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	existing := model.Route{ID: "FOO", Method: "GET,POST", Pattern: "/hello/{name}", Index: 0}
+	var added model.Route
+	origAdd, origList := funcAdd, funcList
+	defer func() { funcAdd, funcList = origAdd, origList }()
+	funcAdd = func(input model.Route) (model.Route, error) {
+		input.Index = 1
+		added = input
+		return input, nil
+	}
+	funcList = func() []model.Route { return []model.Route{existing, added} }
+
+	addRoute(resp, req)
+
+	if resp.Code != http.StatusCreated {
+		t.Errorf("HTTP status mismatch. Expected: %d, got: %d", http.StatusCreated, resp.Code)
+	}
+	var created struct {
+		ID       string   `json:"id"`
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &created); err != nil {
+		t.Errorf("Invalid JSON response. %s", resp.Body.String())
+	}
+	if created.ID != added.ID {
+		t.Errorf("Route ID mismatch. Expected: %q, got: %q", added.ID, created.ID)
+	}
+	if len(created.Warnings) != 1 || !strings.Contains(created.Warnings[0], "FOO") {
+		t.Errorf("Expected a warning about route FOO, got: %q", created.Warnings)
+	}
+}
+
+func TestAddRoute409sWhenStrictAndDuplicated(t *testing.T) {
+	reqPayload := `{"method": "POST,GET", "url_pattern": "/hello", "command": "echo"}`
+	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	existing := []model.Route{{ID: "FOO", Method: "GET,POST", Pattern: "/hello"}}
+	changed := false
+	origTransaction := funcTransaction
+	defer func() { funcTransaction, StrictRoutes = origTransaction, false }()
+	funcTransaction = func(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+		rs, err := fn(existing)
+		changed = err == nil
+		return rs, err
+	}
+	StrictRoutes = true
+
+	addRoute(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusConflict, "Duplicated Route") {
+		t.Error(e)
+	}
+	if changed {
+		t.Error("Duplicated route added")
+	}
+}
+
+func TestInsertRouteWhenStrictChecksAndInsertsInTheSameTransaction(t *testing.T) {
+	reqPayload := `{"method": "GET", "url_pattern": "/hello", "headers": {"Accept": "json"}, "command": "echo", "index": 0}`
+	req := httptest.NewRequest(http.MethodPut, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	existing := []model.Route{{ID: "FOO", Method: "GET", Pattern: "/hello"}}
+	var result []model.Route
+	origTransaction, origList := funcTransaction, funcList
+	defer func() { funcTransaction, funcList, StrictRoutes = origTransaction, origList, false }()
+	funcTransaction = func(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+		var err error
+		result, err = fn(existing)
+		return result, err
+	}
+	funcList = func() []model.Route { return result }
+	StrictRoutes = true
+
+	insertRoute(resp, req)
+
+	if resp.Code != http.StatusCreated {
+		t.Errorf("Status code mismatch. Expected: %d, got: %d", http.StatusCreated, resp.Code)
+	}
+	if len(result) != 2 || result[0].Pattern != "/hello" || result[1].ID != "FOO" {
+		t.Errorf("Route not inserted first: %+v", result)
+	}
+}
+
+func TestInsertRouteWarnsWhenShadowingLaterRoutes(t *testing.T) {
+	reqPayload := `{"method": "*", "url_pattern": "/{any}", "command": "echo", "index": 0}`
+	req := httptest.NewRequest(http.MethodPut, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	existing := model.Route{ID: "FOO", Method: "GET", Pattern: "/hello", Index: 1}
+	var inserted model.Route
+	origInsert, origList := funcInsert, funcList
+	defer func() { funcInsert, funcList = origInsert, origList }()
+	funcInsert = func(input model.Route) (model.Route, error) {
+		inserted = input
+		return input, nil
+	}
+	funcList = func() []model.Route { return []model.Route{inserted, existing} }
+
+	insertRoute(resp, req)
+
+	var created struct {
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &created); err != nil {
+		t.Errorf("Invalid JSON response. %s", resp.Body.String())
+	}
+	if len(created.Warnings) != 1 || !strings.Contains(created.Warnings[0], "FOO") {
+		t.Errorf("Expected a warning about route FOO, got: %q", created.Warnings)
+	}
+}
+
+func TestInsertRouteReturnsBadRequestWhenMalformedJSONBody(t *testing.T) {
+	reqPayload := `{
+	method": "GET",
+	url_pattern": "/hello",
+	index": 0
+  }`
+
+	req := httptest.NewRequest(http.MethodPut, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+
+	insertRoute(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusBadRequest, "Malformed JSON") {
+		t.Error(e)
+	}
+}
+
+func TestInsertRoute422sWhenNegativeIndex(t *testing.T) {
+	reqPayload := `{
+	"method": "GET",
+	"url_pattern": "/hello",
+	"entrypoint": "/bin/sh -c",
+	"command": "echo Hello World | kapow set /response/body",
+	"index": -1
+  }`
+	req := httptest.NewRequest(http.MethodPut, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	called := false
+	funcInsert = func(input model.Route) (model.Route, error) {
+		called = true
+		return input, nil
+	}
+
+	insertRoute(resp, req)
+
 	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnprocessableEntity, "Invalid Route") {
 		t.Error(e)
 	}
+	if called {
+		t.Error("Route inserted with a negative index")
+	}
+}
+
+func TestInsertRoute422sWhenInvalidRoute(t *testing.T) {
+	reqPayload := `{
+	"method": "GET",
+	"url_pattern": "/he{{o",
+	"index": 0
+}`
+	req := httptest.NewRequest(http.MethodPut, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+
+	insertRoute(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnprocessableEntity, "Invalid Route") {
+		t.Error(e)
+	}
+}
+
+func TestInsertRouteReturnsCreatedWithTheEffectiveIndex(t *testing.T) {
+	reqPayload := `{
+	"method": "GET",
+	"url_pattern": "/hello",
+	"entrypoint": "/bin/sh -c",
+	"command": "echo Hello World | kapow set /response/body",
+	"index": 42
+  }`
+	req := httptest.NewRequest(http.MethodPut, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+	var gotIndex int
+	funcInsert = func(input model.Route) (model.Route, error) {
+		gotIndex = input.Index
+		input.Index = 1
+		return input, nil
+	}
+
+	insertRoute(resp, req)
+
+	if resp.Code != http.StatusCreated {
+		t.Errorf("HTTP status mismatch. Expected: %d, got: %d", http.StatusCreated, resp.Code)
+	}
+
+	if ct := resp.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Incorrect content type in response. Expected: application/json, got: %s", ct)
+	}
+
+	if gotIndex != 42 {
+		t.Errorf("Requested index not passed through. Expected: 42, got: %d", gotIndex)
+	}
+
+	respJson := model.Route{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &respJson); err != nil {
+		t.Errorf("Invalid JSON response. %s", resp.Body.String())
+	}
+
+	if _, err := uuid.Parse(respJson.ID); err != nil {
+		t.Error("ID not generated properly")
+	}
+
+	if respJson.Index != 1 {
+		t.Errorf("Effective index not returned. Expected: 1, got: %d", respJson.Index)
+	}
+}
+
+func TestReplaceRouteReturnsBadRequestWhenMalformedJSONBody(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", replaceRoute).
+		Methods("PUT")
+	r := httptest.NewRequest(http.MethodPut, "/routes/FOO", strings.NewReader(`{"method": `))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	for _, e := range checkErrorResponse(w.Result(), http.StatusBadRequest, "Malformed JSON") {
+		t.Error(e)
+	}
+}
+
+func TestReplaceRoute422sWhenInvalidRoute(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", replaceRoute).
+		Methods("PUT")
+	r := httptest.NewRequest(http.MethodPut, "/routes/FOO", strings.NewReader(`{"method": "GET"}`))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	for _, e := range checkErrorResponse(w.Result(), http.StatusUnprocessableEntity, "Invalid Route") {
+		t.Error(e)
+	}
+}
+
+func TestReplaceRouteReturns404sWhenRouteDoesntExist(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", replaceRoute).
+		Methods("PUT")
+	r := httptest.NewRequest(http.MethodPut, "/routes/FOO", strings.NewReader(`{"method": "GET", "url_pattern": "/hello"}`))
+	w := httptest.NewRecorder()
+	funcUpdate = func(id string, fn func(*model.Route) error) (model.Route, error) {
+		return model.Route{}, errors.New(id)
+	}
+
+	handler.ServeHTTP(w, r)
+
+	for _, e := range checkErrorResponse(w.Result(), http.StatusNotFound, "Route Not Found") {
+		t.Error(e)
+	}
+}
+
+func TestReplaceRouteReplacesEveryField(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", replaceRoute).
+		Methods("PUT")
+	r := httptest.NewRequest(http.MethodPut, "/routes/FOO", strings.NewReader(`{"method": "POST", "url_pattern": "/bye"}`))
+	w := httptest.NewRecorder()
+	funcUpdate = func(id string, fn func(*model.Route) error) (model.Route, error) {
+		route := model.Route{ID: id, Method: "GET", Pattern: "/hello", Command: "echo Hello", Index: 3}
+		err := fn(&route)
+		route.ID = id
+		route.Index = 3
+		return route, err
+	}
+
+	handler.ServeHTTP(w, r)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("HTTP status mismatch. Expected: %d, got: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	respJson := model.Route{}
+	bBytes, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(bBytes, &respJson); err != nil {
+		t.Errorf("Invalid JSON response. %s", string(bBytes))
+	}
+
+	expected := model.Route{ID: "FOO", Method: "POST", Pattern: "/bye", Index: 3}
+	if !reflect.DeepEqual(respJson, expected) {
+		t.Errorf("Response mismatch. Expected %#v, got: %#v", expected, respJson)
+	}
+}
+
+func TestReplaceRouteWhenStrict409sWhenDuplicatingAnotherRoute(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", replaceRoute).
+		Methods("PUT")
+	r := httptest.NewRequest(http.MethodPut, "/routes/BAR", strings.NewReader(`{"method": "GET", "url_pattern": "/hello"}`))
+	w := httptest.NewRecorder()
+	existing := []model.Route{{ID: "FOO", Method: "GET", Pattern: "/hello"}, {ID: "BAR", Method: "GET", Pattern: "/bye"}}
+	changed := false
+	origTransaction := funcTransaction
+	defer func() { funcTransaction, StrictRoutes = origTransaction, false }()
+	funcTransaction = func(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+		rs, err := fn(existing)
+		changed = err == nil
+		return rs, err
+	}
+	StrictRoutes = true
+
+	handler.ServeHTTP(w, r)
+
+	for _, e := range checkErrorResponse(w.Result(), http.StatusConflict, "Duplicated Route") {
+		t.Error(e)
+	}
+	if changed {
+		t.Error("Route changed into a duplicate")
+	}
+}
+
+func TestPatchRouteWhenStrictChangesRoutesAlreadyDuplicated(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", patchRoute).
+		Methods("PATCH")
+	r := httptest.NewRequest(http.MethodPatch, "/routes/BAR", strings.NewReader(`{"command": "echo Bye"}`))
+	w := httptest.NewRecorder()
+	existing := []model.Route{{ID: "FOO", Method: "GET", Pattern: "/hello"}, {ID: "BAR", Method: "GET", Pattern: "/hello"}}
+	var result []model.Route
+	origTransaction := funcTransaction
+	defer func() { funcTransaction, StrictRoutes = origTransaction, false }()
+	funcTransaction = func(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+		var err error
+		result, err = fn(existing)
+		return result, err
+	}
+	StrictRoutes = true
+
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("HTTP status mismatch. Expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	if len(result) != 2 || result[1].ID != "BAR" || result[1].Command != "echo Bye" {
+		t.Errorf("Route not patched: %+v", result)
+	}
+}
+
+func TestPatchRouteReturnsBadRequestWhenMalformedJSONBody(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", patchRoute).
+		Methods("PATCH")
+	r := httptest.NewRequest(http.MethodPatch, "/routes/FOO", strings.NewReader(`{"command": `))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	for _, e := range checkErrorResponse(w.Result(), http.StatusBadRequest, "Malformed JSON") {
+		t.Error(e)
+	}
+}
+
+func TestPatchRouteModifiesOnlyTheGivenFields(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", patchRoute).
+		Methods("PATCH")
+	r := httptest.NewRequest(http.MethodPatch, "/routes/FOO", strings.NewReader(`{"command": "echo Bye"}`))
+	w := httptest.NewRecorder()
+	funcUpdate = func(id string, fn func(*model.Route) error) (model.Route, error) {
+		route := model.Route{ID: id, Method: "GET", Pattern: "/hello", Command: "echo Hello"}
+		err := fn(&route)
+		return route, err
+	}
+
+	handler.ServeHTTP(w, r)
+
+	respJson := model.Route{}
+	bBytes, _ := ioutil.ReadAll(w.Result().Body)
+	if err := json.Unmarshal(bBytes, &respJson); err != nil {
+		t.Errorf("Invalid JSON response. %s", string(bBytes))
+	}
+
+	expected := model.Route{ID: "FOO", Method: "GET", Pattern: "/hello", Command: "echo Bye"}
+	if !reflect.DeepEqual(respJson, expected) {
+		t.Errorf("Response mismatch. Expected %#v, got: %#v", expected, respJson)
+	}
+}
+
+func TestPatchRouteReplacesMapsWithoutTouchingTheCurrentOnes(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", patchRoute).
+		Methods("PATCH")
+	r := httptest.NewRequest(http.MethodPatch, "/routes/FOO", strings.NewReader(`{"headers": {"X-Bar": "bar"}}`))
+	w := httptest.NewRecorder()
+	current := map[string]string{"X-Foo": "foo"}
+	var patched model.Route
+	funcUpdate = func(id string, fn func(*model.Route) error) (model.Route, error) {
+		route := model.Route{ID: id, Method: "GET", Pattern: "/hello", Headers: current}
+		err := fn(&route)
+		patched = route
+		return route, err
+	}
+
+	handler.ServeHTTP(w, r)
+
+	if !reflect.DeepEqual(patched.Headers, map[string]string{"X-Bar": "bar"}) {
+		t.Errorf("Headers not replaced: %v", patched.Headers)
+	}
+	if !reflect.DeepEqual(current, map[string]string{"X-Foo": "foo"}) {
+		t.Errorf("Current headers modified: %v", current)
+	}
+}
+
+func TestPatchRoute422sWhenResultingRouteIsInvalid(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", patchRoute).
+		Methods("PATCH")
+	r := httptest.NewRequest(http.MethodPatch, "/routes/FOO", strings.NewReader(`{"url_pattern": ""}`))
+	w := httptest.NewRecorder()
+	funcUpdate = func(id string, fn func(*model.Route) error) (model.Route, error) {
+		route := model.Route{ID: id, Method: "GET", Pattern: "/hello"}
+		if err := fn(&route); err != nil {
+			return model.Route{}, err
+		}
+		return route, nil
+	}
+
+	handler.ServeHTTP(w, r)
+
+	for _, e := range checkErrorResponse(w.Result(), http.StatusUnprocessableEntity, "Invalid Route") {
+		t.Error(e)
+	}
 }
 
 func TestRemoveRouteReturnsNotFound(t *testing.T) {
@@ -357,6 +898,23 @@ func TestRemoveRouteReturnsNotFound(t *testing.T) {
 	}
 }
 
+func TestRemoveRouteReturns500WhenTheChangeCannotBePersisted(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/routes/ROUTE_XXXXXXXXXXXXXXXXXX", nil)
+	resp := httptest.NewRecorder()
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", removeRoute).
+		Methods("DELETE")
+	funcRemove = func(id string) error {
+		return user.ErrPersist
+	}
+
+	handler.ServeHTTP(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusInternalServerError, "Unable to Persist Routes") {
+		t.Error(e)
+	}
+}
+
 func TestRemoveRouteReturnsNoContent(t *testing.T) {
 	req := httptest.NewRequest(http.MethodDelete, "/routes/ROUTE_XXXXXXXXXXXXXXXXXX", nil)
 	resp := httptest.NewRecorder()
@@ -434,6 +992,81 @@ func TestListRoutesReturnsTwoElementsList(t *testing.T) {
 	}
 }
 
+func TestListRoutesMasksTheEnvWhenRequested(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/routes?mask_env=true", nil)
+	resp := httptest.NewRecorder()
+	env := map[string]string{"TOKEN": "s3cr3t"}
+	origList := funcList
+	defer func() { funcList = origList }()
+	funcList = func() []model.Route {
+		return []model.Route{{ID: "FOO", Method: "GET", Pattern: "/hello", Env: env}}
+	}
+
+	listRoutes(resp, req)
+
+	respJson := []model.Route{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &respJson); err != nil {
+		t.Errorf("Invalid JSON response. %s", resp.Body.String())
+	}
+	if len(respJson) != 1 || !reflect.DeepEqual(respJson[0].Env, map[string]string{"TOKEN": maskedValue}) {
+		t.Errorf("Env not masked: %s", resp.Body.String())
+	}
+	if env["TOKEN"] != "s3cr3t" {
+		t.Error("Env of the route table modified")
+	}
+}
+
+func TestGetAndUpdateRouteMaskTheEnvWhenRequested(t *testing.T) {
+	env := map[string]string{"TOKEN": "s3cr3t"}
+	origGet, origUpdate := funcGet, funcUpdate
+	defer func() { funcGet, funcUpdate = origGet, origUpdate }()
+	funcGet = func(id string) (model.Route, error) {
+		return model.Route{ID: id, Env: env}, nil
+	}
+	funcUpdate = func(id string, fn func(*model.Route) error) (model.Route, error) {
+		route := model.Route{ID: id, Env: env}
+		err := fn(&route)
+		return route, err
+	}
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}", getRoute).
+		Methods("GET")
+	handler.HandleFunc("/routes/{id}/enable", enableRoute).
+		Methods("POST")
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/routes/FOO?mask_env=true", nil),
+		httptest.NewRequest(http.MethodPost, "/routes/FOO/enable?mask_env=true", nil),
+	} {
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+
+		var respJson model.Route
+		if err := json.Unmarshal(w.Body.Bytes(), &respJson); err != nil {
+			t.Errorf("Invalid JSON response. %s", w.Body.String())
+		}
+		if !reflect.DeepEqual(respJson.Env, map[string]string{"TOKEN": maskedValue}) {
+			t.Errorf("%s %s: env not masked: %s", r.Method, r.URL.Path, w.Body.String())
+		}
+	}
+	if env["TOKEN"] != "s3cr3t" {
+		t.Error("Env of the route table modified")
+	}
+}
+
+func TestAddRoute422sWhenInvalidEnv(t *testing.T) {
+	reqPayload := `{"method": "GET", "url_pattern": "/hello", "env": {"A=B": "C"}, "command": "echo"}`
+	req := httptest.NewRequest(http.MethodPost, "/routes", strings.NewReader(reqPayload))
+	resp := httptest.NewRecorder()
+
+	addRoute(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnprocessableEntity, "Invalid Route") {
+		t.Error(e)
+	}
+}
+
 func TestGetRouteReturns404sWhenRouteDoesntExist(t *testing.T) {
 	handler := mux.NewRouter()
 	handler.HandleFunc("/routes/{id}", getRoute).
@@ -493,3 +1126,69 @@ func TestGetRouteReturnsTheRequestedRoute(t *testing.T) {
 		t.Errorf(`Route mismatch. Expected: "FOO". Got: %s`, respJson.ID)
 	}
 }
+
+func TestDisableRouteClearsTheEnabledFlag(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}/disable", disableRoute).
+		Methods("POST")
+	r := httptest.NewRequest(http.MethodPost, "/routes/FOO/disable", nil)
+	w := httptest.NewRecorder()
+	funcUpdate = func(id string, fn func(*model.Route) error) (model.Route, error) {
+		route := model.Route{ID: id, Method: "GET", Pattern: "/hello"}
+		err := fn(&route)
+		return route, err
+	}
+
+	handler.ServeHTTP(w, r)
+
+	respJson := model.Route{}
+	bBytes, _ := ioutil.ReadAll(w.Result().Body)
+	if err := json.Unmarshal(bBytes, &respJson); err != nil {
+		t.Errorf("Invalid JSON response. %s", string(bBytes))
+	}
+	if respJson.ID != "FOO" || respJson.IsEnabled() {
+		t.Errorf("Route not disabled: %s", string(bBytes))
+	}
+}
+
+func TestEnableRouteSetsTheEnabledFlag(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}/enable", enableRoute).
+		Methods("POST")
+	r := httptest.NewRequest(http.MethodPost, "/routes/FOO/enable", nil)
+	w := httptest.NewRecorder()
+	disabled := false
+	funcUpdate = func(id string, fn func(*model.Route) error) (model.Route, error) {
+		route := model.Route{ID: id, Method: "GET", Pattern: "/hello", Enabled: &disabled}
+		err := fn(&route)
+		return route, err
+	}
+
+	handler.ServeHTTP(w, r)
+
+	respJson := model.Route{}
+	bBytes, _ := ioutil.ReadAll(w.Result().Body)
+	if err := json.Unmarshal(bBytes, &respJson); err != nil {
+		t.Errorf("Invalid JSON response. %s", string(bBytes))
+	}
+	if !respJson.IsEnabled() {
+		t.Errorf("Route not enabled: %s", string(bBytes))
+	}
+}
+
+func TestEnableRoute404sWhenRouteNotFound(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/routes/{id}/enable", enableRoute).
+		Methods("POST")
+	r := httptest.NewRequest(http.MethodPost, "/routes/FOO/enable", nil)
+	w := httptest.NewRecorder()
+	funcUpdate = func(id string, fn func(*model.Route) error) (model.Route, error) {
+		return model.Route{}, user.ErrRouteNotFound
+	}
+
+	handler.ServeHTTP(w, r)
+
+	for _, e := range checkErrorResponse(w.Result(), http.StatusNotFound, "Route Not Found") {
+		t.Error(e)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/BBVA/kapow/internal/server/httperror"
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user"
+)
+
+// funcSubscribe Method used to ask the route model module for the events of
+// the route table changes
+var funcSubscribe func() (<-chan model.Event, func()) = user.Routes.Subscribe
+
+// watchEvents Handler that streams the changes of the route table as
+// Server-Sent Events, until the client goes away or falls behind
+func watchEvents(res http.ResponseWriter, req *http.Request) {
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		httperror.ErrorJSON(res, "Streaming Not Supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, cancel := funcSubscribe()
+	defer cancel()
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			e.Route = maskRoute(req, e.Route)
+			eBytes, _ := json.Marshal(e)
+			_, _ = fmt.Fprintf(res, "event: %s\ndata: %s\n\n", e.Type, eBytes)
+			flusher.Flush()
+		}
+	}
+}
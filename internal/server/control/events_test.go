@@ -0,0 +1,83 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func TestWatchEventsMasksTheEnvWhenRequested(t *testing.T) {
+	events := make(chan model.Event, 1)
+	events <- model.Event{Type: model.EventAdded, Route: model.Route{ID: "FOO", Env: map[string]string{"TOKEN": "s3cr3t"}}}
+	close(events)
+	origSubscribe := funcSubscribe
+	defer func() { funcSubscribe = origSubscribe }()
+	funcSubscribe = func() (<-chan model.Event, func()) {
+		return events, func() {}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/events?mask_env=true", nil)
+	resp := httptest.NewRecorder()
+
+	watchEvents(resp, req)
+
+	body := resp.Body.String()
+	if strings.Contains(body, "s3cr3t") || !strings.Contains(body, maskedValue) {
+		t.Errorf("Env not masked: %q", body)
+	}
+}
+
+func TestWatchEventsStreamsEachEvent(t *testing.T) {
+	events := make(chan model.Event, 2)
+	events <- model.Event{Type: model.EventAdded, Revision: 1, Route: model.Route{ID: "FOO"}}
+	events <- model.Event{Type: model.EventDeleted, Revision: 2, Route: model.Route{ID: "FOO"}}
+	close(events)
+	cancelled := false
+	origSubscribe := funcSubscribe
+	defer func() { funcSubscribe = origSubscribe }()
+	funcSubscribe = func() (<-chan model.Event, func()) {
+		return events, func() { cancelled = true }
+	}
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	resp := httptest.NewRecorder()
+
+	watchEvents(resp, req)
+
+	res := resp.Result()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("HTTP status mismatch. Expected: %d, got: %d", http.StatusOK, res.StatusCode)
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type header mismatch. Expected: %q, got: %q", "text/event-stream", ct)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	expected := "event: added\n" +
+		`data: {"type":"added","revision":1,"timestamp":"0001-01-01T00:00:00Z","route":{"id":"FOO","method":"","url_pattern":"","entrypoint":"","command":"","index":0}}` + "\n\n" +
+		"event: deleted\n" +
+		`data: {"type":"deleted","revision":2,"timestamp":"0001-01-01T00:00:00Z","route":{"id":"FOO","method":"","url_pattern":"","entrypoint":"","command":"","index":0}}` + "\n\n"
+	if string(body) != expected {
+		t.Errorf("Body mismatch. Expected: %q, got: %q", expected, body)
+	}
+	if !cancelled {
+		t.Error("Subscription not cancelled")
+	}
+}
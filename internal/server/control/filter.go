@@ -0,0 +1,119 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/BBVA/kapow/internal/server/model"
+	usermux "github.com/BBVA/kapow/internal/server/user/mux"
+)
+
+// errInvalidSelector is returned when a label selector can't be parsed
+var errInvalidSelector = errors.New("Invalid Selector")
+
+// requirement is a single condition of a label selector
+type requirement struct {
+	key, value string
+	// equal tells whether the label must (or must not) have the value
+	equal bool
+	// exists tells whether the condition is just about the label presence
+	exists bool
+}
+
+func (req requirement) matches(labels map[string]string) bool {
+	value, found := labels[req.key]
+	if req.exists {
+		return found == req.equal
+	}
+	return (found && value == req.value) == req.equal
+}
+
+// parseSelector parses a label selector: a comma separated list of
+// requirements, each of them being key=value, key==value, key!=value, key
+// (the label is present) or !key (the label is absent)
+func parseSelector(selector string) ([]requirement, error) {
+	reqs := []requirement{}
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		var req requirement
+		switch {
+		case term == "":
+			continue
+		case strings.Contains(term, "!="):
+			kv := strings.SplitN(term, "!=", 2)
+			req = requirement{key: kv[0], value: kv[1]}
+		case strings.Contains(term, "=="):
+			kv := strings.SplitN(term, "==", 2)
+			req = requirement{key: kv[0], value: kv[1], equal: true}
+		case strings.Contains(term, "="):
+			kv := strings.SplitN(term, "=", 2)
+			req = requirement{key: kv[0], value: kv[1], equal: true}
+		case strings.HasPrefix(term, "!"):
+			req = requirement{key: term[1:], exists: true}
+		default:
+			req = requirement{key: term, exists: true, equal: true}
+		}
+		req.key = strings.TrimSpace(req.key)
+		req.value = strings.TrimSpace(req.value)
+		if req.key == "" {
+			return nil, errInvalidSelector
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// routeFilter Builds a function telling whether a route complies with the
+// filters given in query: a label selector, a method served by the route and
+// a prefix of its pattern
+func routeFilter(query url.Values) (func(model.Route) bool, error) {
+	reqs, err := parseSelector(query.Get("selector"))
+	if err != nil {
+		return nil, err
+	}
+	method := query.Get("method")
+	prefix := query.Get("pattern_prefix")
+
+	return func(r model.Route) bool {
+		for _, req := range reqs {
+			if !req.matches(r.Labels) {
+				return false
+			}
+		}
+		if method != "" && !servesMethod(r, method) {
+			return false
+		}
+		return strings.HasPrefix(r.Pattern, prefix)
+	}, nil
+}
+
+// servesMethod Checks whether the route accepts the given HTTP method
+func servesMethod(r model.Route, method string) bool {
+	ms := usermux.Methods(r.Method)
+	if ms == nil {
+		return true
+	}
+	for _, m := range ms {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
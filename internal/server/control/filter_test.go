@@ -0,0 +1,114 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func TestParseSelectorRejectsEmptyKeys(t *testing.T) {
+	for _, selector := range []string{"=foo", "!=foo", "!", "team=billing,==foo"} {
+		if _, err := parseSelector(selector); err != errInvalidSelector {
+			t.Errorf("Invalid selector %q not rejected", selector)
+		}
+	}
+}
+
+func TestRouteFilterAppliesEveryCondition(t *testing.T) {
+	billing := model.Route{ID: "billing", Method: "GET,HEAD", Pattern: "/billing/invoices", Labels: map[string]string{"team": "billing", "env": "prod"}}
+	search := model.Route{ID: "search", Method: "POST", Pattern: "/search", Labels: map[string]string{"team": "search"}}
+	wildcard := model.Route{ID: "any", Method: "*", Pattern: "/billing/ping"}
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"billing", "search", "any"}},
+		{"selector=team%3Dbilling", []string{"billing"}},
+		{"selector=team%3D%3Dbilling", []string{"billing"}},
+		{"selector=team%21%3Dbilling", []string{"search", "any"}},
+		{"selector=team", []string{"billing", "search"}},
+		{"selector=%21team", []string{"any"}},
+		{"selector=team%3Dbilling,env%3Ddev", []string{}},
+		{"method=head", []string{"billing", "any"}},
+		{"pattern_prefix=/billing", []string{"billing", "any"}},
+		{"method=POST&pattern_prefix=/billing", []string{"any"}},
+	}
+
+	for _, tc := range testCases {
+		query, _ := url.ParseQuery(tc.query)
+		filter, err := routeFilter(query)
+		if err != nil {
+			t.Errorf("%q: Unexpected error %v", tc.query, err)
+			continue
+		}
+		ids := []string{}
+		for _, r := range []model.Route{billing, search, wildcard} {
+			if filter(r) {
+				ids = append(ids, r.ID)
+			}
+		}
+		if len(ids) != len(tc.expected) {
+			t.Errorf("%q: Expected %v, got %v", tc.query, tc.expected, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tc.expected[i] {
+				t.Errorf("%q: Expected %v, got %v", tc.query, tc.expected, ids)
+				break
+			}
+		}
+	}
+}
+
+func TestListRoutesFiltersByTheQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/routes?selector=team%3Dbilling", nil)
+	resp := httptest.NewRecorder()
+	funcList = func() []model.Route {
+		return []model.Route{
+			{ID: "FOO", Labels: map[string]string{"team": "billing"}},
+			{ID: "BAR", Labels: map[string]string{"team": "search"}},
+		}
+	}
+
+	listRoutes(resp, req)
+
+	rs := []model.Route{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &rs); err != nil {
+		t.Errorf("Invalid JSON response. %s", resp.Body.String())
+	}
+	if len(rs) != 1 || rs[0].ID != "FOO" {
+		t.Errorf("Unexpected routes: %s", resp.Body.String())
+	}
+}
+
+func TestListRoutes400sWhenInvalidSelector(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/routes?selector=%3Dfoo", nil)
+	resp := httptest.NewRecorder()
+
+	listRoutes(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusBadRequest, "Invalid Selector") {
+		t.Error(e)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/BBVA/kapow/internal/server/httperror"
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user"
+	usermux "github.com/BBVA/kapow/internal/server/user/mux"
+)
+
+// matchRequest describes the request to be matched against the route table
+type matchRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+}
+
+// funcExplain Method used to ask the user server which route would handle a
+// request
+var funcExplain func(*http.Request) model.Explanation = func(req *http.Request) model.Explanation {
+	return usermux.Explain(user.Routes.List(), req)
+}
+
+// matchRoute Handler that tells which route would handle the described
+// request, the variables it would extract and the earlier routes that only
+// failed to match because of the method
+func matchRoute(res http.ResponseWriter, req *http.Request) {
+	var mr matchRequest
+
+	payload, _ := ioutil.ReadAll(req.Body)
+	if err := json.Unmarshal(payload, &mr); err != nil {
+		httperror.ErrorJSON(res, "Malformed JSON", http.StatusBadRequest)
+		return
+	}
+
+	if mr.Method == "" {
+		mr.Method = http.MethodGet
+	}
+	target, err := http.NewRequest(mr.Method, mr.URL, nil)
+	if mr.URL == "" || err != nil {
+		httperror.ErrorJSON(res, "Invalid Request", http.StatusUnprocessableEntity)
+		return
+	}
+	for k, v := range mr.Headers {
+		target.Header.Set(k, v)
+	}
+	if host := target.Header.Get("Host"); host != "" {
+		target.Host = host
+	}
+
+	exp := funcExplain(target)
+	if exp.Route != nil {
+		r := maskRoute(req, *exp.Route)
+		exp.Route = &r
+	}
+	exp.MethodMismatches = maskRoutes(req, exp.MethodMismatches)
+
+	expBytes, _ := json.Marshal(exp)
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(expBytes)
+}
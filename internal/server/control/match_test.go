@@ -0,0 +1,112 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func TestMatchRouteReturnsTheExplanation(t *testing.T) {
+	var got *http.Request
+	origExplain := funcExplain
+	defer func() { funcExplain = origExplain }()
+	funcExplain = func(req *http.Request) model.Explanation {
+		got = req
+		return model.Explanation{
+			Route:            &model.Route{ID: "FOO"},
+			Matches:          map[string]string{"name": "bar"},
+			MethodMismatches: []model.Route{},
+		}
+	}
+	req := httptest.NewRequest(http.MethodPost, "/match", strings.NewReader(`{"method":"POST","url":"/some/bar","headers":{"Host":"example.com","X-Foo":"baz"}}`))
+	resp := httptest.NewRecorder()
+
+	matchRoute(resp, req)
+
+	res := resp.Result()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("HTTP status mismatch. Expected: %d, got: %d", http.StatusOK, res.StatusCode)
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type header mismatch. Expected: %q, got: %q", "application/json", ct)
+	}
+	var exp model.Explanation
+	if err := json.NewDecoder(res.Body).Decode(&exp); err != nil {
+		t.Errorf("Response body contains invalid JSON entity: %v", err)
+	} else if exp.Route == nil || exp.Route.ID != "FOO" || exp.Matches["name"] != "bar" {
+		t.Errorf("Unexpected explanation: %+v", exp)
+	}
+
+	if got == nil {
+		t.Fatal("Explanation not requested")
+	}
+	if got.Method != http.MethodPost || got.URL.Path != "/some/bar" {
+		t.Errorf("Request mismatch. Expected: POST /some/bar, got: %s %s", got.Method, got.URL.Path)
+	}
+	if got.Host != "example.com" {
+		t.Errorf("Host mismatch. Expected: %q, got: %q", "example.com", got.Host)
+	}
+	if v := got.Header.Get("X-Foo"); v != "baz" {
+		t.Errorf("Header mismatch. Expected: %q, got: %q", "baz", v)
+	}
+}
+
+func TestMatchRouteDefaultsToGET(t *testing.T) {
+	var method string
+	origExplain := funcExplain
+	defer func() { funcExplain = origExplain }()
+	funcExplain = func(req *http.Request) model.Explanation {
+		method = req.Method
+		return model.Explanation{}
+	}
+	req := httptest.NewRequest(http.MethodPost, "/match", strings.NewReader(`{"url":"/some/path"}`))
+	resp := httptest.NewRecorder()
+
+	matchRoute(resp, req)
+
+	if method != http.MethodGet {
+		t.Errorf("Method mismatch. Expected: %q, got: %q", http.MethodGet, method)
+	}
+}
+
+func TestMatchRouteRejectsMalformedJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/match", strings.NewReader(`{`))
+	resp := httptest.NewRecorder()
+
+	matchRoute(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusBadRequest, "Malformed JSON") {
+		t.Error(e)
+	}
+}
+
+func TestMatchRouteRejectsMissingURL(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/match", strings.NewReader(`{"method":"GET"}`))
+	resp := httptest.NewRecorder()
+
+	matchRoute(resp, req)
+
+	for _, e := range checkErrorResponse(resp.Result(), http.StatusUnprocessableEntity, "Invalid Request") {
+		t.Error(e)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/BBVA/kapow/internal/server/httperror"
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user"
+)
+
+// funcHistory Method used to ask the route model module for the revisions of
+// the route table
+var funcHistory func() []model.Revision = user.Routes.History
+
+// listRevisions Handler that retrieves the revisions of the route table that
+// are kept, oldest first
+func listRevisions(res http.ResponseWriter, req *http.Request) {
+	list := funcHistory()
+	if maskRequested(req) {
+		masked := make([]model.Revision, len(list))
+		for i, rev := range list {
+			rev.Routes = maskRoutes(req, rev.Routes)
+			masked[i] = rev
+		}
+		list = masked
+	}
+
+	listBytes, _ := json.Marshal(list)
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(listBytes)
+}
+
+// funcRestore Method used to ask the route model module to restore a revision
+// of the route table
+var funcRestore func(int) ([]model.Route, error) = user.Routes.Restore
+
+// restoreRevision Handler that rolls back the route table to the requested
+// revision. If the revision is not kept returns 404 and an error entity
+func restoreRevision(res http.ResponseWriter, req *http.Request) {
+	n, err := strconv.Atoi(mux.Vars(req)["n"])
+	if err != nil {
+		httperror.ErrorJSON(res, "Revision Not Found", http.StatusNotFound)
+		return
+	}
+
+	rs, err := funcRestore(n)
+	if err == user.ErrPersist {
+		httperror.ErrorJSON(res, "Unable to Persist Routes", http.StatusInternalServerError)
+		return
+	} else if err != nil {
+		httperror.ErrorJSON(res, "Revision Not Found", http.StatusNotFound)
+		return
+	}
+
+	rsBytes, _ := json.Marshal(maskRoutes(req, rs))
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(rsBytes)
+}
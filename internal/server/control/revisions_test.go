@@ -0,0 +1,115 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func TestListRevisionsReturnsTheHistory(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/revisions", nil)
+	resp := httptest.NewRecorder()
+	funcHistory = func() []model.Revision {
+		return []model.Revision{{Number: 1, Operation: "append"}, {Number: 2, Operation: "delete"}}
+	}
+
+	listRevisions(resp, req)
+
+	if ct := resp.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Incorrect content type in response. Expected: application/json, got: %s", ct)
+	}
+
+	var hs []model.Revision
+	if err := json.Unmarshal(resp.Body.Bytes(), &hs); err != nil {
+		t.Fatalf("Invalid JSON response. %s", resp.Body.String())
+	}
+	if len(hs) != 2 || hs[1].Operation != "delete" {
+		t.Errorf("Response mismatch: %+v", hs)
+	}
+}
+
+func TestListRevisionsMasksTheEnvWhenRequested(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/revisions?mask_env=true", nil)
+	resp := httptest.NewRecorder()
+	env := map[string]string{"TOKEN": "s3cr3t"}
+	funcHistory = func() []model.Revision {
+		return []model.Revision{{Number: 1, Routes: []model.Route{{ID: "FOO", Env: env}}}}
+	}
+
+	listRevisions(resp, req)
+
+	var hs []model.Revision
+	if err := json.Unmarshal(resp.Body.Bytes(), &hs); err != nil {
+		t.Fatalf("Invalid JSON response. %s", resp.Body.String())
+	}
+	if len(hs) != 1 || len(hs[0].Routes) != 1 || hs[0].Routes[0].Env["TOKEN"] != maskedValue {
+		t.Errorf("Env not masked: %s", resp.Body.String())
+	}
+	if env["TOKEN"] != "s3cr3t" {
+		t.Error("Env of the history modified")
+	}
+}
+
+func TestRestoreRevisionReturns404sWhenRevisionDoesntExist(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/revisions/{n}/restore", restoreRevision).
+		Methods("POST")
+	funcRestore = func(n int) ([]model.Route, error) {
+		return nil, errors.New("Revision not found")
+	}
+
+	for _, path := range []string{"/revisions/42/restore", "/revisions/FOO/restore"} {
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+
+		for _, e := range checkErrorResponse(w.Result(), http.StatusNotFound, "Revision Not Found") {
+			t.Error(e)
+		}
+	}
+}
+
+func TestRestoreRevisionRestoresTheRequestedRevision(t *testing.T) {
+	handler := mux.NewRouter()
+	handler.HandleFunc("/revisions/{n}/restore", restoreRevision).
+		Methods("POST")
+	var got int
+	funcRestore = func(n int) ([]model.Route, error) {
+		got = n
+		return []model.Route{{ID: "FOO"}}, nil
+	}
+	r := httptest.NewRequest(http.MethodPost, "/revisions/42/restore", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("HTTP status mismatch. Expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	if got != 42 {
+		t.Errorf("Revision mismatch. Expected: 42, got: %d", got)
+	}
+}
@@ -17,23 +17,50 @@
 package control
 
 import (
+	"crypto/tls"
 	"log"
 	"net"
 	"net/http"
 	"sync"
+
+	"github.com/BBVA/kapow/internal/server/certs"
 )
 
-// Run Starts the control server listening in bindAddr
-func Run(bindAddr string, wg *sync.WaitGroup) {
+// Run Starts the control server listening in bindAddr.  If any tokens are
+// given, requests must present one of them as a bearer token.  The server is
+// served through https when certFile and keyFile are given, and with cliAuth
+// the clients must also present a certificate issued by a CA in cliCaFile.
+func Run(bindAddr string, wg *sync.WaitGroup, tokens []string, certFile, keyFile, cliCaFile string, cliAuth bool) {
+	authTokens = tokens
+	server := http.Server{
+		Addr:    bindAddr,
+		Handler: configRouter(),
+	}
 
 	listener, err := net.Listen("tcp", bindAddr)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Signal startup
-	log.Printf("ControlServer listening at %s\n", bindAddr)
-	wg.Done()
+	if (certFile != "") && (keyFile != "") {
+		if cliAuth {
+			server.TLSConfig, err = certs.ClientAuthConfig(cliCaFile, tls.RequireAndVerifyClientCert)
+			if err != nil {
+				log.Fatalf("ControlServer failed to load CA certs: %s\n", err)
+			}
+			log.Printf("ControlServer using CA certs from %s\n", certs.CAStoreName(cliCaFile))
+		}
+
+		// Signal startup
+		log.Printf("ControlServer listening at %s\n", bindAddr)
+		wg.Done()
 
-	log.Fatal(http.Serve(listener, configRouter()))
+		log.Fatal(server.ServeTLS(listener, certFile, keyFile))
+	} else {
+		// Signal startup
+		log.Printf("ControlServer listening at %s\n", bindAddr)
+		wg.Done()
+
+		log.Fatal(server.Serve(listener))
+	}
 }
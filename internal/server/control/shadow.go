@@ -0,0 +1,261 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/BBVA/kapow/internal/server/model"
+	usermux "github.com/BBVA/kapow/internal/server/user/mux"
+)
+
+// StrictRoutes makes every endpoint adding or changing routes reject the ones
+// with the same methods, pattern and matchers as an existing one
+var StrictRoutes = false
+
+// duplicated Tells whether rs already has a route, other than r itself, with
+// the same methods, pattern and matchers as r
+func duplicated(rs []model.Route, r model.Route) bool {
+	for _, e := range rs {
+		if (r.ID == "" || e.ID != r.ID) && sameTarget(e, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// introducesDuplicate Tells whether applying op to rs would leave two routes
+// with the same methods, pattern and matchers.  An update keeping those of
+// the route it changes never does, so routes duplicated before StrictRoutes
+// was set can still be changed otherwise
+func introducesDuplicate(rs []model.Route, op model.Operation) bool {
+	if op.Route == nil {
+		return false
+	}
+	r := *op.Route
+	if op.Op == model.OpUpdate {
+		r.ID = op.ID
+		for _, e := range rs {
+			if e.ID == op.ID && sameTarget(e, r) {
+				return false
+			}
+		}
+	}
+	return duplicated(rs, r)
+}
+
+// sameTarget Tells whether a and b have the same methods, pattern and
+// matchers, and so match the very same requests
+func sameTarget(a, b model.Route) bool {
+	return a.Pattern == b.Pattern && sameMethods(a.Method, b.Method) && sameMatchers(a, b)
+}
+
+// sameMatchers Tells whether a and b have the same host, headers, queries and
+// schemes matchers.  Header names and schemes are compared case-insensitively
+// and schemes regardless of their order
+func sameMatchers(a, b model.Route) bool {
+	return a.Host == b.Host &&
+		reflect.DeepEqual(canonicalHeaders(a.Headers), canonicalHeaders(b.Headers)) &&
+		reflect.DeepEqual(nonNil(a.Queries), nonNil(b.Queries)) &&
+		reflect.DeepEqual(schemeSet(a.Schemes), schemeSet(b.Schemes))
+}
+
+func canonicalHeaders(headers map[string]string) map[string]string {
+	m := make(map[string]string, len(headers))
+	for k, v := range headers {
+		m[http.CanonicalHeaderKey(k)] = v
+	}
+	return m
+}
+
+func nonNil(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
+
+func schemeSet(schemes []string) map[string]bool {
+	set := make(map[string]bool, len(schemes))
+	for _, s := range schemes {
+		set[strings.ToLower(s)] = true
+	}
+	return set
+}
+
+// shadowWarnings Analyzes the route identified by id within rs and warns
+// about the earlier routes that leave it unreachable and the later ones it
+// leaves unreachable
+func shadowWarnings(rs []model.Route, id string) []string {
+	pos := -1
+	for i, r := range rs {
+		if r.ID == id {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 {
+		return nil
+	}
+
+	var warnings []string
+	for i, r := range rs {
+		if i < pos && shadows(r, rs[pos]) {
+			warnings = append(warnings, fmt.Sprintf("Route is unreachable: route %s at index %d matches every request it would", r.ID, i))
+		} else if i > pos && shadows(rs[pos], r) {
+			warnings = append(warnings, fmt.Sprintf("Route makes route %s at index %d unreachable: it matches every request that one would", r.ID, i))
+		}
+	}
+	return warnings
+}
+
+// shadows Tells whether the route a, placed before b, matches every request
+// b would match, leaving it unreachable
+func shadows(a, b model.Route) bool {
+	if !a.IsEnabled() && !usermux.RespondDisabled {
+		return false
+	}
+	if !coversMethods(a.Method, b.Method) {
+		return false
+	}
+	// Only a route without matchers, or with the very same ones, is known
+	// to match everything the other one does
+	if a.Host != "" || len(a.Headers) > 0 || len(a.Queries) > 0 || len(a.Schemes) > 0 {
+		if !sameMatchers(a, b) {
+			return false
+		}
+	}
+	return coversPattern(a.Pattern, b.Pattern)
+}
+
+// methodSet Returns the methods listed in method, or nil for any method
+func methodSet(method string) map[string]bool {
+	ms := usermux.Methods(method)
+	if ms == nil {
+		return nil
+	}
+	set := make(map[string]bool, len(ms))
+	for _, m := range ms {
+		set[strings.ToUpper(m)] = true
+	}
+	return set
+}
+
+// coversMethods Tells whether every method in b is accepted by a
+func coversMethods(a, b string) bool {
+	as, bs := methodSet(a), methodSet(b)
+	if as == nil {
+		return true
+	}
+	if bs == nil {
+		return false
+	}
+	for m := range bs {
+		if !as[m] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameMethods Tells whether a and b accept the same methods
+func sameMethods(a, b string) bool {
+	return coversMethods(a, b) && coversMethods(b, a)
+}
+
+// coversPattern Tells whether every path matching pattern b also matches
+// pattern a.  It is a conservative check: a literal b is covered when it
+// matches the regexp gorilla mux builds for a, a b ending in more segments
+// is covered by an a ending in a catch-all variable ({name:.*} or
+// {name:.+}) with the same leading segments, and otherwise both patterns
+// are compared segment by segment: a variable without a regexp covers any
+// literal segment or variable without regexp, a variable with a regexp
+// covers the literal segments fully matching it and the variables with the
+// same regexp, and any other segment only covers an equal one.
+func coversPattern(a, b string) bool {
+	if !strings.ContainsAny(b, "{}") {
+		return matchesPattern(a, b)
+	}
+
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	last := len(as) - 1
+	if re, ok := variable(as[last]); ok && (re == ".*" || re == ".+") && len(bs) > len(as) {
+		return coversSegments(as[:last], bs[:last])
+	}
+	return len(as) == len(bs) && coversSegments(as, bs)
+}
+
+// coversSegments Tells whether every segment of bs is covered by the one in
+// the same position of as, as described in coversPattern
+func coversSegments(as, bs []string) bool {
+	for i := range as {
+		if !coversSegment(as[i], bs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesPattern Tells whether path matches pattern, as gorilla mux does
+func matchesPattern(pattern, path string) bool {
+	expr, err := mux.NewRouter().NewRoute().Path(pattern).GetPathRegexp()
+	if err != nil {
+		return false
+	}
+	re, err := regexp.Compile(expr)
+	return err == nil && re.MatchString(path)
+}
+
+// coversSegment Tells whether every value matching segment b matches segment
+// a, as described in coversPattern
+func coversSegment(a, b string) bool {
+	aRe, aOk := variable(a)
+	bRe, bOk := variable(b)
+	switch {
+	case !aOk:
+		return a == b
+	case bOk:
+		return aRe == bRe
+	case aRe == "":
+		return b != "" && !strings.ContainsAny(b, "{}")
+	default:
+		re, err := regexp.Compile("^(?:" + aRe + ")$")
+		return err == nil && !strings.ContainsAny(b, "{}") && re.MatchString(b)
+	}
+}
+
+// variable Tells whether segment is made of a single {name} or
+// {name:regexp} variable, returning its regexp
+func variable(segment string) (re string, ok bool) {
+	if len(segment) < 2 || segment[0] != '{' || segment[len(segment)-1] != '}' {
+		return "", false
+	}
+	inner := segment[1 : len(segment)-1]
+	if strings.ContainsAny(inner, "{}") {
+		return "", false
+	}
+	if parts := strings.SplitN(inner, ":", 2); len(parts) == 2 {
+		return parts[1], true
+	}
+	return "", true
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func TestCoversPattern(t *testing.T) {
+	testCases := []struct {
+		a, b   string
+		covers bool
+	}{
+		{"/hello", "/hello", true},
+		{"/hello", "/bye", false},
+		{"/{name}", "/hello", true},
+		{"/{name}", "/{other}", true},
+		{"/{name}", "/hello/world", false},
+		{"/{id:[0-9]+}", "/42", true},
+		{"/{id:[0-9]+}", "/hello", false},
+		{"/{id:[0-9]+}", "/{n:[0-9]+}", true},
+		{"/{id:[0-9]+}", "/{name}", false},
+		{"/hello", "/{name}", false},
+		{"/{name}", "/{id:[0-9]+}", false},
+		{"/file.{ext}", "/file.{ext}", true},
+		{"/file.{ext}", "/file.txt", true},
+		{"/file.{ext}", "/file/txt", false},
+		{"/{path:.*}", "/foo/bar", true},
+		{"/{path:.*}", "/", true},
+		{"/{path:.*}", "/foo/{id}", true},
+		{"/api/{rest:.+}", "/api/{version}/users", true},
+		{"/api/{rest:.+}", "/other/{version}/users", false},
+		{"/api/{rest:.+}", "/api", false},
+		{"/foo/bar", "/{path:.*}", false},
+	}
+
+	for _, tc := range testCases {
+		if got := coversPattern(tc.a, tc.b); got != tc.covers {
+			t.Errorf("coversPattern(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.covers)
+		}
+	}
+}
+
+func TestShadowsChecksMethodsAndMatchers(t *testing.T) {
+	disabled := false
+	testCases := []struct {
+		name    string
+		a, b    model.Route
+		shadows bool
+	}{
+		{"same method", model.Route{Method: "GET", Pattern: "/x"}, model.Route{Method: "GET", Pattern: "/x"}, true},
+		{"other method", model.Route{Method: "GET", Pattern: "/x"}, model.Route{Method: "POST", Pattern: "/x"}, false},
+		{"fewer methods", model.Route{Method: "GET", Pattern: "/x"}, model.Route{Method: "GET,POST", Pattern: "/x"}, false},
+		{"any method", model.Route{Method: "*", Pattern: "/x"}, model.Route{Method: "GET,POST", Pattern: "/x"}, true},
+		{"method case", model.Route{Method: "get", Pattern: "/x"}, model.Route{Method: "GET", Pattern: "/x"}, true},
+		{"matchers", model.Route{Method: "GET", Pattern: "/x", Host: "a.example.com"}, model.Route{Method: "GET", Pattern: "/x"}, false},
+		{"same matchers", model.Route{Method: "GET", Pattern: "/x", Host: "a.example.com"}, model.Route{Method: "GET", Pattern: "/x", Host: "a.example.com"}, true},
+		{"later matchers", model.Route{Method: "GET", Pattern: "/x"}, model.Route{Method: "GET", Pattern: "/x", Host: "a.example.com"}, true},
+		{"disabled", model.Route{Method: "GET", Pattern: "/x", Enabled: &disabled}, model.Route{Method: "GET", Pattern: "/x"}, false},
+	}
+
+	for _, tc := range testCases {
+		if got := shadows(tc.a, tc.b); got != tc.shadows {
+			t.Errorf("%s: shadows = %v, want %v", tc.name, got, tc.shadows)
+		}
+	}
+}
+
+func TestShadowWarningsReportsEarlierCatchAllRoutes(t *testing.T) {
+	rs := []model.Route{
+		{ID: "A", Method: "GET", Pattern: "/{path:.*}"},
+		{ID: "B", Method: "GET", Pattern: "/foo/bar"},
+	}
+
+	if ws := shadowWarnings(rs, "B"); len(ws) != 1 {
+		t.Errorf("Unexpected warnings %v", ws)
+	}
+}
+
+func TestShadowWarningsIgnoresUnrelatedRoutes(t *testing.T) {
+	rs := []model.Route{
+		{ID: "A", Method: "POST", Pattern: "/hello"},
+		{ID: "B", Method: "GET", Pattern: "/hello"},
+		{ID: "C", Method: "GET", Pattern: "/bye"},
+	}
+
+	if ws := shadowWarnings(rs, "B"); len(ws) != 0 {
+		t.Errorf("Unexpected warnings: %q", ws)
+	}
+}
+
+func TestDuplicatedComparesMethodSetsAndPattern(t *testing.T) {
+	rs := []model.Route{{Method: "GET,POST", Pattern: "/hello"}}
+
+	if !duplicated(rs, model.Route{Method: "POST,GET", Pattern: "/hello"}) {
+		t.Error("Duplicate not detected")
+	}
+	if duplicated(rs, model.Route{Method: "GET", Pattern: "/hello"}) {
+		t.Error("Different methods reported as duplicate")
+	}
+	if duplicated(rs, model.Route{Method: "GET,POST", Pattern: "/hello/{name}"}) {
+		t.Error("Different pattern reported as duplicate")
+	}
+}
+
+func TestDuplicatedComparesMatchers(t *testing.T) {
+	rs := []model.Route{{
+		Method:  "POST",
+		Pattern: "/items",
+		Host:    "{tenant}.example.com",
+		Headers: map[string]string{"content-type": "^application/json"},
+		Schemes: []string{"https", "http"},
+	}}
+
+	same := model.Route{
+		Method:  "POST",
+		Pattern: "/items",
+		Host:    "{tenant}.example.com",
+		Headers: map[string]string{"Content-Type": "^application/json"},
+		Schemes: []string{"HTTP", "https"},
+	}
+	if !duplicated(rs, same) {
+		t.Error("Duplicate not detected")
+	}
+
+	testCases := map[string]func(*model.Route){
+		"no headers":  func(r *model.Route) { r.Headers = nil },
+		"other host":  func(r *model.Route) { r.Host = "example.com" },
+		"queries":     func(r *model.Route) { r.Queries = map[string]string{"format": "json"} },
+		"one scheme":  func(r *model.Route) { r.Schemes = []string{"https"} },
+		"other value": func(r *model.Route) { r.Headers = map[string]string{"Content-Type": "^text/plain"} },
+	}
+	for name, change := range testCases {
+		r := same
+		change(&r)
+		if duplicated(rs, r) {
+			t.Errorf("%s: reported as duplicate", name)
+		}
+	}
+}
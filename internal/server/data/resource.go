@@ -17,6 +17,7 @@
 package data
 
 import (
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -172,6 +173,34 @@ func getSSLClietnDN(w http.ResponseWriter, r *http.Request, h *model.Handler) {
 	}
 }
 
+func getRequestAuthUser(w http.ResponseWriter, r *http.Request, h *model.Handler) {
+	if h.AuthUser == "" {
+		httperror.ErrorJSON(w, ResourceItemNotFound, http.StatusNotFound)
+	} else {
+		w.Header().Add("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte(h.AuthUser))
+	}
+}
+
+func getRequestJWTClaim(w http.ResponseWriter, r *http.Request, h *model.Handler) {
+	name := mux.Vars(r)["name"]
+	value, ok := h.JWTClaims[name]
+	if !ok {
+		httperror.ErrorJSON(w, ResourceItemNotFound, http.StatusNotFound)
+		return
+	}
+
+	// Strings are given as is, any other value as JSON
+	var content []byte
+	if s, isString := value.(string); isString {
+		content = []byte(s)
+	} else {
+		content, _ = json.Marshal(value)
+	}
+	w.Header().Add("Content-Type", "application/octet-stream")
+	_, _ = w.Write(content)
+}
+
 func getRouteId(w http.ResponseWriter, r *http.Request, h *model.Handler) {
 	w.Header().Add("Content-Type", "application/octet-stream")
 	_, _ = w.Write([]byte(h.Route.ID))
@@ -193,6 +222,7 @@ func setResponseStatus(w http.ResponseWriter, r *http.Request, h *model.Handler)
 		httperror.ErrorJSON(w, InvalidStatusCode, http.StatusBadRequest)
 	} else {
 		h.Writer.WriteHeader(int(si))
+		h.Sent = true
 	}
 }
 
@@ -221,7 +251,11 @@ func setResponseCookies(w http.ResponseWriter, r *http.Request, h *model.Handler
 }
 
 func setResponseBody(w http.ResponseWriter, r *http.Request, h *model.Handler) {
-	if n, err := io.Copy(h.Writer, r.Body); err != nil {
+	n, err := io.Copy(h.Writer, r.Body)
+	if n > 0 {
+		h.Sent = true
+	}
+	if err != nil {
 		if n > 0 {
 			panic(http.ErrAbortHandler)
 		}
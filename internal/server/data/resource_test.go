@@ -1242,6 +1242,98 @@ func TestGetSSLClientDNReturnsCorrectDN(t *testing.T) {
 	}
 }
 
+func TestGetRequestAuthUser404sWhenNotAuthenticated(t *testing.T) {
+	h := model.Handler{
+		Request: httptest.NewRequest("GET", "/", nil),
+		Writer:  httptest.NewRecorder(),
+	}
+	r := httptest.NewRequest("GET", "/not-important-here", nil)
+	w := httptest.NewRecorder()
+
+	getRequestAuthUser(w, r, &h)
+
+	for _, e := range checkErrorResponse(w.Result(), http.StatusNotFound, ResourceItemNotFound) {
+		t.Error(e)
+	}
+}
+
+func TestGetRequestAuthUserReturnsTheAuthenticatedUser(t *testing.T) {
+	h := model.Handler{
+		Request:  httptest.NewRequest("GET", "/", nil),
+		Writer:   httptest.NewRecorder(),
+		AuthUser: "alice",
+	}
+	r := httptest.NewRequest("GET", "/not-important-here", nil)
+	w := httptest.NewRecorder()
+
+	getRequestAuthUser(w, r, &h)
+
+	res := w.Result()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status code mismatch. Expected: 200, got: %d", res.StatusCode)
+	}
+	if v := res.Header.Get("Content-Type"); v != "application/octet-stream" {
+		t.Errorf("Content Type mismatch. Expected: %q, got: %q", "application/octet-stream", v)
+	}
+	if body, _ := ioutil.ReadAll(res.Body); string(body) != "alice" {
+		t.Errorf("Body mismatch. Expected: %q, got: %q", "alice", string(body))
+	}
+}
+
+func TestGetRequestJWTClaimReturnsStringsAsIs(t *testing.T) {
+	h := model.Handler{
+		Request:   httptest.NewRequest("GET", "/", nil),
+		Writer:    httptest.NewRecorder(),
+		JWTClaims: map[string]interface{}{"sub": "alice"},
+	}
+	r := createMuxRequest("/handlers/HANDLERID/request/jwt/claims/{name}", "/handlers/HANDLERID/request/jwt/claims/sub", "GET", nil)
+	w := httptest.NewRecorder()
+
+	getRequestJWTClaim(w, r, &h)
+
+	res := w.Result()
+	if v := res.Header.Get("Content-Type"); v != "application/octet-stream" {
+		t.Errorf("Content Type mismatch. Expected: %q, got: %q", "application/octet-stream", v)
+	}
+	if body, _ := ioutil.ReadAll(res.Body); string(body) != "alice" {
+		t.Errorf("Body mismatch. Expected: %q, got: %q", "alice", string(body))
+	}
+}
+
+func TestGetRequestJWTClaimReturnsOtherValuesAsJSON(t *testing.T) {
+	h := model.Handler{
+		Request:   httptest.NewRequest("GET", "/", nil),
+		Writer:    httptest.NewRecorder(),
+		JWTClaims: map[string]interface{}{"exp": float64(1600000000), "groups": []interface{}{"admins", "users"}},
+	}
+
+	for name, expected := range map[string]string{"exp": "1600000000", "groups": `["admins","users"]`} {
+		r := createMuxRequest("/handlers/HANDLERID/request/jwt/claims/{name}", "/handlers/HANDLERID/request/jwt/claims/"+name, "GET", nil)
+		w := httptest.NewRecorder()
+
+		getRequestJWTClaim(w, r, &h)
+
+		if body, _ := ioutil.ReadAll(w.Result().Body); string(body) != expected {
+			t.Errorf("Body mismatch for %s. Expected: %q, got: %q", name, expected, string(body))
+		}
+	}
+}
+
+func TestGetRequestJWTClaim404sWhenTheClaimDoesntExist(t *testing.T) {
+	h := model.Handler{
+		Request: httptest.NewRequest("GET", "/", nil),
+		Writer:  httptest.NewRecorder(),
+	}
+	r := createMuxRequest("/handlers/HANDLERID/request/jwt/claims/{name}", "/handlers/HANDLERID/request/jwt/claims/sub", "GET", nil)
+	w := httptest.NewRecorder()
+
+	getRequestJWTClaim(w, r, &h)
+
+	for _, e := range checkErrorResponse(w.Result(), http.StatusNotFound, ResourceItemNotFound) {
+		t.Error(e)
+	}
+}
+
 func TestGetRouteId200sOnHappyPath(t *testing.T) {
 	h := model.Handler{
 		Request: httptest.NewRequest("POST", "/", nil),
@@ -1324,6 +1416,21 @@ func TestSetResponseStatusSetsGivenStatus(t *testing.T) {
 	}
 }
 
+func TestSetResponseStatusMarksTheResponseAsSent(t *testing.T) {
+	h := model.Handler{
+		Request: httptest.NewRequest("POST", "/", nil),
+		Writer:  httptest.NewRecorder(),
+	}
+	r := httptest.NewRequest("PUT", "/", strings.NewReader("418"))
+	w := httptest.NewRecorder()
+
+	setResponseStatus(w, r, &h)
+
+	if !h.Sent {
+		t.Error("Response not marked as sent")
+	}
+}
+
 func TestSetResponseStatus400sWhenNonparseableStatusCode(t *testing.T) {
 	h := model.Handler{
 		Request: httptest.NewRequest("POST", "/", nil),
@@ -1580,6 +1687,21 @@ func TestSetResponseBodySetsTheResponseBody(t *testing.T) {
 	}
 }
 
+func TestSetResponseBodyMarksTheResponseAsSent(t *testing.T) {
+	h := model.Handler{
+		Request: httptest.NewRequest("POST", "/", nil),
+		Writer:  httptest.NewRecorder(),
+	}
+	r := createMuxRequest("/handlers/HANDLERID/response/body", "/handlers/HANDLERID/response/body", "PUT", strings.NewReader("BAZ"))
+	w := httptest.NewRecorder()
+
+	setResponseBody(w, r, &h)
+
+	if !h.Sent {
+		t.Error("Response not marked as sent")
+	}
+}
+
 func TestSetResponseBody500sWhenReaderFailsInFirstRead(t *testing.T) {
 	hw := httptest.NewRecorder()
 	h := model.Handler{
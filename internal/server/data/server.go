@@ -17,11 +17,13 @@
 package data
 
 import (
+	"crypto/tls"
 	"log"
 	"net"
 	"net/http"
 	"sync"
 
+	"github.com/BBVA/kapow/internal/server/certs"
 	"github.com/BBVA/kapow/internal/server/httperror"
 	"github.com/gorilla/mux"
 )
@@ -54,7 +56,10 @@ func configRouter(rs []routeSpec) (r *mux.Router) {
 	return r
 }
 
-func Run(bindAddr string, wg *sync.WaitGroup) {
+// Run Starts the data server listening in bindAddr.  The server is served
+// through https when certFile and keyFile are given, and with cliAuth the
+// clients must also present a certificate issued by a CA in cliCaFile.
+func Run(bindAddr string, wg *sync.WaitGroup, certFile, keyFile, cliCaFile string, cliAuth bool) {
 	rs := []routeSpec{
 		// request
 		{"/handlers/{handlerID}/request/method", "GET", getRequestMethod},
@@ -70,6 +75,8 @@ func Run(bindAddr string, wg *sync.WaitGroup) {
 		{"/handlers/{handlerID}/request/files/{name}/filename", "GET", getRequestFileName},
 		{"/handlers/{handlerID}/request/files/{name}/content", "GET", getRequestFileContent},
 		{"/handlers/{handlerID}/request/body", "GET", getRequestBody},
+		{"/handlers/{handlerID}/request/auth/user", "GET", getRequestAuthUser},
+		{"/handlers/{handlerID}/request/jwt/claims/{name}", "GET", getRequestJWTClaim},
 
 		// route
 		{"/handlers/{handlerID}/route/id", "GET", getRouteId},
@@ -85,14 +92,35 @@ func Run(bindAddr string, wg *sync.WaitGroup) {
 		{"/handlers/{handlerID}/response/stream", "PUT", lockResponseWriter(setResponseBody)},
 	}
 
+	server := http.Server{
+		Addr:    bindAddr,
+		Handler: configRouter(rs),
+	}
+
 	listener, err := net.Listen("tcp", bindAddr)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Signal startup
-	log.Printf("DataServer listening at %s\n", bindAddr)
-	wg.Done()
+	if (certFile != "") && (keyFile != "") {
+		if cliAuth {
+			server.TLSConfig, err = certs.ClientAuthConfig(cliCaFile, tls.RequireAndVerifyClientCert)
+			if err != nil {
+				log.Fatalf("DataServer failed to load CA certs: %s\n", err)
+			}
+			log.Printf("DataServer using CA certs from %s\n", certs.CAStoreName(cliCaFile))
+		}
+
+		// Signal startup
+		log.Printf("DataServer listening at %s\n", bindAddr)
+		wg.Done()
 
-	log.Fatal(http.Serve(listener, configRouter(rs)))
+		log.Fatal(server.ServeTLS(listener, certFile, keyFile))
+	} else {
+		// Signal startup
+		log.Printf("DataServer listening at %s\n", bindAddr)
+		wg.Done()
+
+		log.Fatal(server.Serve(listener))
+	}
 }
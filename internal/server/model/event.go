@@ -0,0 +1,44 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// Event types emitted when the route table changes.
+const (
+	// EventAdded is emitted for a route that was not in the route table.
+	EventAdded = "added"
+	// EventUpdated is emitted for a route whose definition changed.
+	EventUpdated = "updated"
+	// EventDeleted is emitted for a route removed from the route table.
+	EventDeleted = "deleted"
+)
+
+// Event represents a change of a single route in the route table.
+type Event struct {
+	// Type is the kind of change, one of the Event* constants.
+	Type string `json:"type"`
+
+	// Revision is the number of the Revision that produced the change.
+	Revision int `json:"revision"`
+
+	// Timestamp is the moment the change was made.
+	Timestamp time.Time `json:"timestamp"`
+
+	// Route is the route after the change, or before it when deleted.
+	Route Route `json:"route"`
+}
@@ -0,0 +1,30 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+// Explanation tells how the route table handles a request.
+type Explanation struct {
+	// Route is the route that handles the request, or nil if none does.
+	Route *Route `json:"route"`
+
+	// Matches are the variables extracted from the request by Route.
+	Matches map[string]string `json:"matches"`
+
+	// MethodMismatches are the routes before Route that match the
+	// request in everything but its method.
+	MethodMismatches []Route `json:"method_mismatches"`
+}
@@ -41,4 +41,20 @@ type Handler struct {
 
 	// Writer is the original http.ResponseWriter of the request.
 	Writer http.ResponseWriter
+
+	// Sent tells whether the status of the response has already been
+	// written.  It is protected by Writing.
+	Sent bool
+
+	// AuthUser is the user authenticated by the Route, if it requires
+	// authentication.
+	AuthUser string
+
+	// JWTClaims are the claims of the verified JWT bearer token of the
+	// request, if the Route requires one.
+	JWTClaims map[string]interface{}
+
+	// TmpDir is the private temporary directory of this handler, if the
+	// Route asks for one.
+	TmpDir string
 }
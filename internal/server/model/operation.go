@@ -0,0 +1,46 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+// Operation kinds accepted in a batch of route table changes.
+const (
+	// OpAppend appends Route at the end of the route table.
+	OpAppend = "append"
+	// OpInsert inserts Route at the position given by its Index.
+	OpInsert = "insert"
+	// OpUpdate replaces the route identified by ID with Route, keeping
+	// its ID and position.
+	OpUpdate = "update"
+	// OpDelete removes the route identified by ID.
+	OpDelete = "delete"
+	// OpClear removes every route.
+	OpClear = "clear"
+)
+
+// Operation represents a single change to the route table, to be applied
+// along with others as a whole.
+type Operation struct {
+	// Op is the kind of change, one of the Op* constants.
+	Op string `json:"op"`
+
+	// ID is the route affected by update and delete operations.
+	ID string `json:"id,omitempty"`
+
+	// Route is the route definition used by append, insert and update
+	// operations.
+	Route *Route `json:"route,omitempty"`
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// Revision represents the state of the route table right after a change.
+type Revision struct {
+	// Number identifies the Revision.  It grows by one with each change.
+	Number int `json:"number"`
+
+	// Timestamp is the moment the change was made.
+	Timestamp time.Time `json:"timestamp"`
+
+	// Operation is the kind of change that created this Revision.
+	Operation string `json:"operation"`
+
+	// RouteID is the route affected by the change, if it affected a
+	// single one.
+	RouteID string `json:"route_id,omitempty"`
+
+	// Routes is the resulting route table.
+	Routes []Route `json:"routes"`
+}
@@ -16,18 +16,39 @@
 
 package model
 
+// AnyMethod is the Method that matches every HTTP method.
+const AnyMethod = "*"
+
 // Route contains the data needed to represent a Kapow! user route.
 type Route struct {
 	// ID is the unique identifier of the Route.
 	ID string `json:"id"`
 
-	// Method is the HTTP method that will match this Route.
+	// Method is the HTTP method that will match this Route, or a comma
+	// separated list of them.  AnyMethod matches every method.
 	Method string `json:"method"`
 
 	// Pattern is the gorilla/mux path pattern that will match this
 	// Route.
 	Pattern string `json:"url_pattern"`
 
+	// Host is an optional gorilla/mux host pattern that the request
+	// must match.
+	Host string `json:"host,omitempty"`
+
+	// Headers are optional headers that the request must have.  Each
+	// value is a regular expression that the header value must match.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Queries are optional query parameters that the request must have.
+	// Each value is a gorilla/mux pattern that the parameter value must
+	// match.
+	Queries map[string]string `json:"queries,omitempty"`
+
+	// Schemes optionally restrict the URL schemes, http or https, that
+	// will match this Route.
+	Schemes []string `json:"schemes,omitempty"`
+
 	// Entrypoint is the string that will be executed when the Route
 	// match.
 	//
@@ -39,7 +60,141 @@ type Route struct {
 	// executing the Entrypoint
 	Command string `json:"command"`
 
+	// Env are the environment variables set for the Entrypoint on top of
+	// the ones of the server.
+	Env map[string]string `json:"env,omitempty"`
+
+	// Workdir is the working directory of the Entrypoint.  The one of the
+	// server is used when empty.
+	Workdir string `json:"workdir,omitempty"`
+
+	// TmpDir tells whether each request gets a fresh private temporary
+	// directory, removed once the request is served.
+	TmpDir bool `json:"tmpdir,omitempty"`
+
+	// Timeout is the maximum execution time of the Entrypoint, as a Go
+	// duration like "30s".  The default of the server is used when empty
+	// and "0" means no timeout.
+	Timeout string `json:"timeout,omitempty"`
+
+	// DisconnectSignal is the signal, like "SIGTERM", sent to the process
+	// group of the Entrypoint when the client goes away before the
+	// response is complete.  When empty the Entrypoint keeps running.
+	DisconnectSignal string `json:"disconnect_signal,omitempty"`
+
+	// MaxConcurrency is the maximum number of requests to this Route
+	// served at the same time.  Zero means no limit.
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+
+	// MaxQueue is the maximum number of requests waiting for their turn
+	// when MaxConcurrency is reached.  The exceeding ones are rejected.
+	MaxQueue int `json:"max_queue,omitempty"`
+
+	// QueueTimeout is the maximum time a request waits for its turn, as a
+	// Go duration like "10s".  When empty it waits as long as needed.
+	QueueTimeout string `json:"queue_timeout,omitempty"`
+
+	// RateLimit optionally throttles the requests of each client.
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+
+	// Auth optionally requires the requests to be authenticated.
+	Auth *Auth `json:"auth,omitempty"`
+
+	// JWT optionally requires the requests to carry a valid JWT bearer
+	// token.
+	JWT *JWT `json:"jwt,omitempty"`
+
+	// ClientCert optionally requires the requests to present a client
+	// certificate satisfying some rules.
+	ClientCert *ClientCert `json:"client_cert,omitempty"`
+
+	// Description is a free-form text explaining the purpose of this
+	// Route.
+	Description string `json:"description,omitempty"`
+
+	// Labels are free-form key/value pairs used to classify this Route.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Enabled tells whether this Route serves requests.  A nil value
+	// means enabled, so routes are enabled unless stated otherwise.
+	Enabled *bool `json:"enabled,omitempty"`
+
 	// Index is this route position in the server's routes list.
 	// It is an output field, its value is ignored as input.
 	Index int `json:"index"`
 }
+
+// RateLimit throttles the requests to a Route with a token bucket per client.
+type RateLimit struct {
+	// Requests is the number of requests allowed to each client every
+	// Per.
+	Requests int `json:"requests"`
+
+	// Per is the period of Requests, as a Go duration like "1m".  It is
+	// one second when empty.
+	Per string `json:"per,omitempty"`
+
+	// Burst is the maximum number of requests a client can make at once.
+	// It is Requests when zero.
+	Burst int `json:"burst,omitempty"`
+
+	// Key tells how clients are told apart: "ip", the default, or
+	// "header:NAME" to use the value of the NAME request header, like an
+	// API key.
+	Key string `json:"key,omitempty"`
+
+	// TrustedHeader is a header, like X-Forwarded-For, set by a trusted
+	// proxy.  When keyed by ip, its last address is used as the client IP
+	// instead of the remote address of the connection.
+	TrustedHeader string `json:"trusted_header,omitempty"`
+}
+
+// Auth requires the requests to a Route to authenticate with HTTP Basic
+// credentials.
+type Auth struct {
+	// HtpasswdFile is the path of the htpasswd file with the accepted
+	// users.  Their passwords must be hashed with bcrypt, SHA-1 or
+	// SHA-256/512 crypt.
+	HtpasswdFile string `json:"htpasswd_file"`
+
+	// Realm is the realm announced to the clients.
+	Realm string `json:"realm,omitempty"`
+}
+
+// JWT requires the requests to a Route to carry a JWT bearer token signed by
+// one of the keys of KeysFile.
+type JWT struct {
+	// KeysFile is the path of a JWKS or PEM file with the public keys
+	// that sign the accepted tokens.
+	KeysFile string `json:"keys_file"`
+
+	// Issuer, if set, must match the iss claim of the tokens.
+	Issuer string `json:"issuer,omitempty"`
+
+	// Audience, if set, must be included in the aud claim of the tokens.
+	Audience string `json:"audience,omitempty"`
+}
+
+// ClientCert requires the requests to a Route to present a verified client
+// certificate.  Each field is a list of regular expressions that must match
+// the whole value; for every non empty one, some value of the certificate
+// must match some of them.
+type ClientCert struct {
+	// DN are the patterns of the subject distinguished name.
+	DN []string `json:"dn,omitempty"`
+
+	// CN are the patterns of the subject common name.
+	CN []string `json:"cn,omitempty"`
+
+	// OU are the patterns of the subject organizational units.
+	OU []string `json:"ou,omitempty"`
+
+	// SAN are the patterns of the subject alternative names: DNS names,
+	// email addresses, IP addresses and URIs.
+	SAN []string `json:"san,omitempty"`
+}
+
+// IsEnabled reports whether the Route serves requests
+func (r Route) IsEnabled() bool {
+	return r.Enabled == nil || *r.Enabled
+}
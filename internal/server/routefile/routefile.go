@@ -0,0 +1,231 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package routefile implements the declarative route definition files that
+// can be loaded by kapow server.
+//
+// A route file is a YAML or JSON document holding a list of routes, with the
+// same fields used by the control API:
+//
+//	- method: GET
+//	  url_pattern: /hello
+//	  command: echo Hello World | kapow set /response/body
+//
+// When omitted, entrypoint defaults to "/bin/sh -c" and id to a UUID derived
+// from the methods, pattern and matchers of the route.
+package routefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/BBVA/kapow/internal/server/model"
+	usermux "github.com/BBVA/kapow/internal/server/user/mux"
+)
+
+// DefaultEntrypoint is used for the routes that don't declare one
+const DefaultEntrypoint = "/bin/sh -c"
+
+// IsRouteFile tells whether the given path looks like a route file, judging
+// by its extension
+func IsRouteFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// Load reads and validates the routes defined in the given file
+func Load(path string) ([]model.Route, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, content)
+}
+
+// Parse validates the routes defined in content.  name is only used to
+// report errors, in the form "name:line: reason".
+func Parse(name string, content []byte) ([]model.Route, error) {
+	return parse(name, content, true)
+}
+
+// Decode reads the routes defined in content like Parse, but only checks that
+// they are well formed.  It is meant for routes that will be sent to another
+// Kapow! server, which validates them against its own files and settings.
+func Decode(name string, content []byte) ([]model.Route, error) {
+	return parse(name, content, false)
+}
+
+// parse reads the routes defined in content, validating them if told so
+func parse(name string, content []byte, validate bool) ([]model.Route, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	// An empty file defines no routes
+	if len(doc.Content) == 0 {
+		return []model.Route{}, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s:%d: expected a list of routes", name, root.Line)
+	}
+
+	rs := make([]model.Route, 0, len(root.Content))
+	ids := make(map[string]int)
+	for _, node := range root.Content {
+		r, err := parseRoute(node, validate)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%v", name, err)
+		}
+
+		if line, ok := ids[r.ID]; ok {
+			return nil, fmt.Errorf("%s:%d: duplicated id %q, already used at line %d", name, node.Line, r.ID, line)
+		}
+		ids[r.ID] = node.Line
+
+		// Keep the index of exported routes, so they can be imported in
+		// their original order
+		if !hasField(node, "index") {
+			r.Index = len(rs)
+		}
+		rs = append(rs, r)
+	}
+
+	return rs, nil
+}
+
+// lineError is an error located at a given line of the route file
+type lineError struct {
+	line   int
+	reason string
+}
+
+func (e lineError) Error() string {
+	return fmt.Sprintf("%d: %s", e.line, e.reason)
+}
+
+// parseRoute converts a YAML node into a route, checking that it only
+// contains known fields of the right type and, if told so, that it is valid
+func parseRoute(node *yaml.Node, validate bool) (r model.Route, err error) {
+	if node.Kind != yaml.MappingNode {
+		return r, lineError{node.Line, "expected a route definition"}
+	}
+
+	fields := routeFields()
+	lines := make(map[string]int)
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !fields[key.Value] {
+			return r, lineError{key.Line, fmt.Sprintf("unknown field %q", key.Value)}
+		}
+		lines[key.Value] = key.Line
+	}
+
+	// Reuse the JSON representation of model.Route so the file format is
+	// always the same one used by the control API
+	var v interface{}
+	if err = node.Decode(&v); err != nil {
+		return r, lineError{node.Line, err.Error()}
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return r, lineError{node.Line, err.Error()}
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&r); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			field := strings.SplitN(typeErr.Field, ".", 2)[0]
+			return r, lineError{lines[field], fmt.Sprintf("invalid value for field %q, expected %s", field, typeErr.Type)}
+		}
+		return r, lineError{node.Line, err.Error()}
+	}
+
+	if validate {
+		if err = usermux.ValidateRoute(r); err != nil {
+			line := node.Line
+			if invalid, ok := err.(usermux.InvalidRouteError); ok && lines[invalid.Field] != 0 {
+				line = lines[invalid.Field]
+			}
+			return r, lineError{line, err.Error()}
+		}
+		r.Method = usermux.NormalizeMethod(r.Method)
+	}
+
+	if r.Entrypoint == "" {
+		r.Entrypoint = DefaultEntrypoint
+	}
+	if r.ID == "" {
+		r.ID = stableID(r)
+	}
+
+	return r, nil
+}
+
+// idNamespace is the namespace of the IDs derived by stableID
+var idNamespace = uuid.MustParse("6f1f3c2e-6d3b-4a39-9a5e-6b1d8c1f4a52")
+
+// stableID derives an ID for a route without one from its methods, pattern
+// and matchers, so loading the same file again replaces the route instead of
+// adding a copy of it
+func stableID(r model.Route) string {
+	key, _ := json.Marshal(struct {
+		Method  string            `json:"method"`
+		Pattern string            `json:"url_pattern"`
+		Host    string            `json:"host"`
+		Headers map[string]string `json:"headers"`
+		Queries map[string]string `json:"queries"`
+		Schemes []string          `json:"schemes"`
+	}{r.Method, r.Pattern, r.Host, r.Headers, r.Queries, r.Schemes})
+	return uuid.NewSHA1(idNamespace, key).String()
+}
+
+// hasField tells whether the route definition node sets the given field
+func hasField(node *yaml.Node, name string) bool {
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// routeFields returns the set of field names accepted in a route definition
+func routeFields() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(model.Route{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package routefile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsRouteFileRecognizesJSONAndYAML(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"routes.json": true,
+		"routes.yaml": true,
+		"routes.YML":  true,
+		"routes.pow":  false,
+		"routes":      false,
+	} {
+		if IsRouteFile(path) != expected {
+			t.Errorf("IsRouteFile(%q) mismatch, expected %v", path, expected)
+		}
+	}
+}
+
+func TestParseReadsYAMLRoutes(t *testing.T) {
+	content := `
+- method: GET
+  url_pattern: /hello
+  command: echo Hello World | kapow set /response/body
+- id: BAR
+  method: POST
+  url_pattern: /bye
+  entrypoint: /bin/bash -c
+  command: echo Bye
+`
+	rs, err := Parse("routes.yaml", []byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if len(rs) != 2 {
+		t.Fatalf("Expected 2 routes, got %d", len(rs))
+	}
+	if rs[0].Method != "GET" || rs[0].Pattern != "/hello" || rs[0].Command != "echo Hello World | kapow set /response/body" {
+		t.Errorf("First route mismatch: %+v", rs[0])
+	}
+	if rs[0].Entrypoint != DefaultEntrypoint {
+		t.Errorf("Default entrypoint not applied: %q", rs[0].Entrypoint)
+	}
+	if rs[0].ID == "" {
+		t.Error("ID not generated")
+	}
+	if rs[1].ID != "BAR" || rs[1].Entrypoint != "/bin/bash -c" || rs[1].Index != 1 {
+		t.Errorf("Second route mismatch: %+v", rs[1])
+	}
+}
+
+func TestParseDerivesStableIDs(t *testing.T) {
+	content := "- {method: GET, url_pattern: /a}\n- {method: GET, url_pattern: /b}\n"
+
+	first, err := Parse("routes.yaml", []byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	second, _ := Parse("routes.yaml", []byte(content))
+
+	if first[0].ID != second[0].ID || first[1].ID != second[1].ID {
+		t.Errorf("IDs change between loads: %s, %s and %s, %s", first[0].ID, first[1].ID, second[0].ID, second[1].ID)
+	}
+	if first[0].ID == first[1].ID {
+		t.Errorf("Different routes got the same ID %s", first[0].ID)
+	}
+}
+
+func TestParseKeepsTheGivenIndexes(t *testing.T) {
+	content := `[
+  {"id": "B", "method": "GET", "url_pattern": "/second", "index": 1},
+  {"id": "A", "method": "GET", "url_pattern": "/first", "index": 0},
+  {"id": "C", "method": "GET", "url_pattern": "/third"}
+]`
+	rs, err := Parse("routes.json", []byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if rs[0].Index != 1 || rs[1].Index != 0 || rs[2].Index != 2 {
+		t.Errorf("Indexes mismatch: got %d, %d and %d, want 1, 0 and 2", rs[0].Index, rs[1].Index, rs[2].Index)
+	}
+}
+
+func TestParseNormalizesTheMethod(t *testing.T) {
+	rs, err := Parse("routes.yaml", []byte("- {method: 'head, get', url_pattern: /a}\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if rs[0].Method != "GET,HEAD" {
+		t.Errorf("Method not normalized: %q", rs[0].Method)
+	}
+}
+
+func TestDecodeLeavesTheValidationToTheServer(t *testing.T) {
+	content := "- method: GET\n  url_pattern: /hello\n  auth: {htpasswd_file: /nonexistent/kapow/htpasswd}\n"
+
+	if _, err := Parse("routes.yaml", []byte(content)); err == nil {
+		t.Error("Parse didn't validate the route")
+	}
+	rs, err := Decode("routes.yaml", []byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(rs) != 1 || rs[0].Auth == nil || rs[0].Auth.HtpasswdFile != "/nonexistent/kapow/htpasswd" {
+		t.Errorf("Routes mismatch: %+v", rs)
+	}
+}
+
+func TestDecodeRejectsMalformedRoutes(t *testing.T) {
+	if _, err := Decode("routes.yaml", []byte("- method: GET\n  methdo: POST\n")); err == nil {
+		t.Error("Expected error not returned")
+	}
+}
+
+func TestParseReadsJSONRoutes(t *testing.T) {
+	content := "[\n\t{\n\t\t\"method\": \"GET\",\n\t\t\"url_pattern\": \"/hello\"\n\t}\n]\n"
+
+	rs, err := Parse("routes.json", []byte(content))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if len(rs) != 1 || rs[0].Pattern != "/hello" {
+		t.Errorf("Routes mismatch: %+v", rs)
+	}
+}
+
+func TestParseAcceptsAnEmptyFile(t *testing.T) {
+	rs, err := Parse("routes.yaml", []byte(""))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if len(rs) != 0 {
+		t.Errorf("Unexpected routes: %+v", rs)
+	}
+}
+
+func TestParseReportsFileAndLineOfErrors(t *testing.T) {
+	testCases := []struct {
+		name, content, expected string
+	}{
+		{"NotAList", "method: GET\n", "routes.yaml:1: expected a list of routes"},
+		{"NotAMapping", "- GET\n", "routes.yaml:1: expected a route definition"},
+		{"UnknownField", "- method: GET\n  url_pattern: /hello\n  methdo: POST\n", `routes.yaml:3: unknown field "methdo"`},
+		{"WrongType", "- method: GET\n  url_pattern: [/hello]\n", `routes.yaml:2: invalid value for field "url_pattern"`},
+		{"MissingMethod", "- url_pattern: /hello\n", `routes.yaml:1: missing mandatory field "method"`},
+		{"MissingPattern", "- method: GET\n", `routes.yaml:1: missing mandatory field "url_pattern"`},
+		{"InvalidPattern", "- method: GET\n\n  url_pattern: /he{{o\n", "routes.yaml:3: invalid url_pattern"},
+		{"InvalidMatchers", "- method: GET\n  url_pattern: /hello\n  schemes: [ftp]\n", "routes.yaml:1: invalid matchers"},
+		{"InvalidEnv", "- method: GET\n  url_pattern: /hello\n  env: {\"A=B\": C}\n", "routes.yaml:1: invalid execution settings"},
+		{"InvalidTimeout", "- method: GET\n  url_pattern: /hello\n  timeout: soon\n", "routes.yaml:1: invalid execution settings"},
+		{"InvalidLimits", "- method: GET\n  url_pattern: /hello\n  max_queue: 5\n", "routes.yaml:1: invalid limits"},
+		{"InvalidAuth", "- method: GET\n  url_pattern: /hello\n  auth: {realm: admins}\n", "routes.yaml:1: invalid auth"},
+		{"InvalidJWT", "- method: GET\n  url_pattern: /hello\n  jwt: {issuer: me}\n", "routes.yaml:1: invalid auth"},
+		{"InvalidClientCert", "- method: GET\n  url_pattern: /hello\n  client_cert: {cn: [\"(\"]}\n", "routes.yaml:1: invalid auth"},
+		{"DuplicatedID", "- {id: FOO, method: GET, url_pattern: /a}\n- {id: FOO, method: GET, url_pattern: /b}\n", `routes.yaml:2: duplicated id "FOO"`},
+		{"SyntaxError", "- method: GET\n  url_pattern: \"/hello\n", "routes.yaml: yaml: line 2"},
+	}
+
+	for _, tc := range testCases {
+		_, err := Parse("routes.yaml", []byte(tc.content))
+		if err == nil {
+			t.Errorf("%s: Expected error not returned", tc.name)
+		} else if !strings.HasPrefix(err.Error(), tc.expected) {
+			t.Errorf("%s: Error mismatch. Expected prefix: %q, got: %q", tc.name, tc.expected, err)
+		}
+	}
+}
@@ -17,11 +17,17 @@
 package server
 
 import (
+	"log"
 	"sync"
+	"time"
 
 	"github.com/BBVA/kapow/internal/server/control"
 	"github.com/BBVA/kapow/internal/server/data"
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/routefile"
 	"github.com/BBVA/kapow/internal/server/user"
+	usermux "github.com/BBVA/kapow/internal/server/user/mux"
+	"github.com/BBVA/kapow/internal/server/user/spawn"
 )
 
 type ServerConfig struct {
@@ -30,19 +36,97 @@ type ServerConfig struct {
 	UserBindAddr,
 	KeyFile,
 	CertFile,
-	ClientCaFile string
+	ClientCaFile,
+	StateFile string
 
 	ClientAuth bool
+
+	// ClientAuthOptional makes the user server verify the client
+	// certificates only when given, instead of requiring them.
+	ClientAuthOptional bool
+
+	ControlKeyFile,
+	ControlCertFile,
+	ControlClientCaFile string
+
+	ControlClientAuth bool
+
+	DataKeyFile,
+	DataCertFile,
+	DataClientCaFile string
+
+	DataClientAuth bool
+
+	// RespondDisabled makes the disabled routes answer 503 Service
+	// Unavailable instead of being skipped.
+	RespondDisabled bool
+
+	// StrictRoutes makes the control server reject the changes leaving two
+	// routes with the same methods, pattern and matchers.
+	StrictRoutes bool
+
+	// Timeout is the execution timeout of the routes that don't set their
+	// own.  Zero means no timeout.
+	Timeout time.Duration
+
+	// ControlTokens are the bearer tokens accepted by the control server.
+	// The control API is left unauthenticated when empty.
+	ControlTokens []string
 }
 
 // StartServer Starts one instance of each server in a goroutine and remains listening on a channel for trace events generated by them
 func StartServer(config ServerConfig) {
+	if config.StateFile != "" {
+		if err := user.Routes.Load(config.StateFile); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Routes loaded from %s\n", config.StateFile)
+	}
+
+	usermux.RespondDisabled = config.RespondDisabled
+	control.StrictRoutes = config.StrictRoutes
+	spawn.DefaultTimeout = config.Timeout
+
 	var wg = sync.WaitGroup{}
 	wg.Add(3)
-	go control.Run(config.ControlBindAddr, &wg)
-	go data.Run(config.DataBindAddr, &wg)
-	go user.Run(config.UserBindAddr, &wg, config.CertFile, config.KeyFile, config.ClientCaFile, config.ClientAuth)
+	go control.Run(config.ControlBindAddr, &wg, config.ControlTokens, config.ControlCertFile, config.ControlKeyFile, config.ControlClientCaFile, config.ControlClientAuth)
+	go data.Run(config.DataBindAddr, &wg, config.DataCertFile, config.DataKeyFile, config.DataClientCaFile, config.DataClientAuth)
+	go user.Run(config.UserBindAddr, &wg, config.CertFile, config.KeyFile, config.ClientCaFile, config.ClientAuth, config.ClientAuthOptional)
 
 	// Wait for servers signals in order to return
 	wg.Wait()
 }
+
+// LoadRouteFile adds to the route table all the routes defined in the given
+// route file, in a single change.  The routes whose ID is already in the
+// table replace the existing ones in place, and the rest are appended.
+// Nothing is changed if any of them is invalid.
+func LoadRouteFile(path string) error {
+	rs, err := routefile.Load(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = user.Routes.Transaction(func(current []model.Route) ([]model.Route, error) {
+		return mergeRoutes(current, rs), nil
+	})
+	return err
+}
+
+// mergeRoutes returns current with the routes of rs replacing the ones with
+// the same ID and the rest appended, in order
+func mergeRoutes(current, rs []model.Route) []model.Route {
+	positions := make(map[string]int, len(current))
+	for i, r := range current {
+		positions[r.ID] = i
+	}
+	for _, r := range rs {
+		if i, ok := positions[r.ID]; ok {
+			current[i] = r
+		} else {
+			positions[r.ID] = len(current)
+			current = append(current, r)
+		}
+	}
+	return current
+}
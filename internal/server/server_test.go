@@ -0,0 +1,73 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user"
+)
+
+func TestMergeRoutesReplacesExistingIDsAndAppendsTheRest(t *testing.T) {
+	current := []model.Route{{ID: "a", Command: "old a"}, {ID: "b", Command: "old b"}}
+	rs := []model.Route{{ID: "c", Command: "new c"}, {ID: "a", Command: "new a"}}
+
+	merged := mergeRoutes(current, rs)
+
+	expected := []string{"a:new a", "b:old b", "c:new c"}
+	if len(merged) != len(expected) {
+		t.Fatalf("Unexpected routes %+v", merged)
+	}
+	for i, r := range merged {
+		if r.ID+":"+r.Command != expected[i] {
+			t.Errorf("Route %d: expected %s, got %s:%s", i, expected[i], r.ID, r.Command)
+		}
+	}
+}
+
+func TestLoadRouteFileTwiceDoesNotDuplicateRoutes(t *testing.T) {
+	defer func(rs []model.Route) {
+		_, _ = user.Routes.Transaction(func([]model.Route) ([]model.Route, error) { return rs, nil })
+	}(user.Routes.List())
+	_, _ = user.Routes.Transaction(func([]model.Route) ([]model.Route, error) { return []model.Route{}, nil })
+
+	dir, err := ioutil.TempDir("", "kapow-routefile-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "routes.yaml")
+	content := "- id: echo\n  method: POST\n  url_pattern: /echo\n  command: kapow get /request/body | kapow set /response/body\n" +
+		"- method: GET\n  url_pattern: /hello\n  command: echo Hello | kapow set /response/body\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := LoadRouteFile(path); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+
+	if rs := user.Routes.List(); len(rs) != 2 || rs[0].ID != "echo" {
+		t.Errorf("Unexpected routes %+v", rs)
+	}
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package auth implements the authentication of the requests to the routes
+// that ask for it.
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GehirnInc/crypt"
+	"github.com/GehirnInc/crypt/sha256_crypt"
+	"github.com/GehirnInc/crypt/sha512_crypt"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// DefaultRealm is the realm of the routes that don't set one
+const DefaultRealm = "kapow"
+
+// watchedFile is a file that is read again whenever it changes
+type watchedFile struct {
+	path    string
+	read    bool
+	modTime time.Time
+	size    int64
+}
+
+// changes returns the content of the file if it changed since the last call,
+// or nil otherwise
+func (f *watchedFile) changes() ([]byte, error) {
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if f.read && fi.ModTime().Equal(f.modTime) && fi.Size() == f.size {
+		return nil, nil
+	}
+	content, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	f.read, f.modTime, f.size = true, fi.ModTime(), fi.Size()
+	return content, nil
+}
+
+// Htpasswd is an htpasswd file, reloaded whenever it changes
+type Htpasswd struct {
+	m     sync.Mutex
+	file  watchedFile
+	users map[string]string
+}
+
+// htpasswdFiles holds the htpasswd files by path, so the routes using the
+// same one share it
+var htpasswdFiles = struct {
+	sync.Mutex
+	byPath map[string]*Htpasswd
+}{byPath: map[string]*Htpasswd{}}
+
+// HtpasswdFile returns the htpasswd file at path
+func HtpasswdFile(path string) *Htpasswd {
+	htpasswdFiles.Lock()
+	defer htpasswdFiles.Unlock()
+
+	h, ok := htpasswdFiles.byPath[path]
+	if !ok {
+		h = &Htpasswd{file: watchedFile{path: path}}
+		htpasswdFiles.byPath[path] = h
+	}
+	return h
+}
+
+// Authenticate tells whether user and password match an entry of the file,
+// reading it again first if it changed since the last time
+func (h *Htpasswd) Authenticate(user, password string) (bool, error) {
+	h.m.Lock()
+	content, err := h.file.changes()
+	if err != nil {
+		h.m.Unlock()
+		return false, err
+	}
+	if content != nil {
+		h.users = ParseHtpasswd(content)
+	}
+	hash, ok := h.users[user]
+	h.m.Unlock()
+
+	if !ok {
+		// Spend the same time as with an existing user, so the response
+		// time doesn't tell which users exist
+		verify(dummyHash, password)
+		return false, nil
+	}
+	return verify(hash, password), nil
+}
+
+// dummyHash is a bcrypt hash checked against the passwords of unknown users
+const dummyHash = "$2a$10$zzeQixl3jT/mWguBQlQVYurNUYS9g0rVI8efPVgIWXeu1K28Loc/u"
+
+// ParseHtpasswd returns the password hashes of the user:hash lines of an
+// htpasswd file by user, skipping blank lines and # comments.  The users whose
+// hash is not supported are logged and skipped.
+func ParseHtpasswd(content []byte) map[string]string {
+	users := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if err := checkHash(kv[1]); err != nil {
+			log.Printf("Ignoring htpasswd user %q: %v\n", kv[0], err)
+			continue
+		}
+		users[kv[0]] = kv[1]
+	}
+	return users
+}
+
+// MaxSHACryptRounds is the most rounds accepted in a SHA-crypt hash, as every
+// login attempt of its user runs all of them
+const MaxSHACryptRounds = 100000
+
+// checkHash tells why hash can't be used by verify, if it can't
+func checkHash(hash string) error {
+	if c, ok := shaCrypter(hash); ok {
+		rounds, err := c.Cost(hash)
+		if err != nil {
+			return fmt.Errorf("invalid SHA-crypt hash: %v", err)
+		}
+		if rounds > MaxSHACryptRounds {
+			return fmt.Errorf("too many SHA-crypt rounds, %d, at most %d are allowed", rounds, MaxSHACryptRounds)
+		}
+		return nil
+	}
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$", "{SHA}"} {
+		if strings.HasPrefix(hash, prefix) {
+			return nil
+		}
+	}
+	return errors.New("unsupported password hash")
+}
+
+// shaCrypter returns the SHA-crypt implementation for hash, if it is a
+// SHA-256 ($5$) or SHA-512 ($6$) crypt one
+func shaCrypter(hash string) (crypt.Crypter, bool) {
+	switch {
+	case strings.HasPrefix(hash, "$5$"):
+		return sha256_crypt.New(), true
+	case strings.HasPrefix(hash, "$6$"):
+		return sha512_crypt.New(), true
+	}
+	return nil, false
+}
+
+// verify tells whether password matches hash.  Only the bcrypt ($2a$, $2b$
+// and $2y$), SHA-1 ({SHA}) and SHA-crypt ($5$ and $6$) variants accepted by
+// checkHash are supported.
+func verify(hash, password string) bool {
+	if checkHash(hash) != nil {
+		return false
+	}
+	if c, ok := shaCrypter(hash); ok {
+		return c.Verify(hash, []byte(password)) == nil
+	}
+	if strings.HasPrefix(hash, "{SHA}") {
+		sum := sha1.Sum([]byte(password))
+		expected := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(expected)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// Basic authenticates req with the HTTP Basic credentials checked against the
+// htpasswd file of a. It returns the authenticated user or, when the
+// credentials are missing or wrong, answers 401 Unauthorized and returns
+// false.
+func Basic(w http.ResponseWriter, req *http.Request, a model.Auth) (string, bool) {
+	if user, password, ok := req.BasicAuth(); ok {
+		valid, err := HtpasswdFile(a.HtpasswdFile).Authenticate(user, password)
+		if err != nil {
+			log.Printf("Unable to read htpasswd file: %v\n", err)
+		}
+		if valid {
+			return user, true
+		}
+	}
+
+	realm := a.Realm
+	if realm == "" {
+		realm = DefaultRealm
+	}
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", realm))
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	return "", false
+}
+
+// Validate checks that the authentication settings of r can be applied
+func Validate(r model.Route) error {
+	if r.Auth != nil && r.JWT != nil {
+		return errors.New("auth and jwt can't be used together")
+	}
+	if r.Auth != nil {
+		if r.Auth.HtpasswdFile == "" {
+			return errors.New("missing htpasswd_file")
+		}
+		if _, err := os.Stat(r.Auth.HtpasswdFile); err != nil {
+			return fmt.Errorf("unable to read htpasswd file: %v", err)
+		}
+	}
+	if r.JWT != nil {
+		if r.JWT.KeysFile == "" {
+			return errors.New("missing keys_file")
+		}
+		if _, err := KeySetFile(r.JWT.KeysFile).Keys(); err != nil {
+			return fmt.Errorf("unable to read keys file: %v", err)
+		}
+	}
+	if r.ClientCert != nil {
+		if _, err := compileCertRules(*r.ClientCert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
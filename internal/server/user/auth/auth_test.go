@@ -0,0 +1,262 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// "{SHA}" + base64(sha1("secret"))
+const shaSecret = "{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ="
+
+func writeHtpasswd(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "kapow-auth-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "htpasswd")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestParseHtpasswdSkipsCommentsAndBlankLines(t *testing.T) {
+	users := ParseHtpasswd([]byte("# users\n\nalice:" + shaSecret + "\nbogus\n"))
+
+	if len(users) != 1 || users["alice"] != shaSecret {
+		t.Errorf("Unexpected users %v", users)
+	}
+}
+
+func TestVerifyAcceptsSHA(t *testing.T) {
+	if !verify(shaSecret, "secret") {
+		t.Error("SHA password rejected")
+	}
+	if verify(shaSecret, "wrong") {
+		t.Error("Wrong SHA password accepted")
+	}
+}
+
+func TestVerifyAcceptsBcryptVariants(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		h := prefix + string(hash[4:])
+		if !verify(h, "secret") {
+			t.Errorf("%s password rejected", prefix)
+		}
+		if verify(h, "wrong") {
+			t.Errorf("Wrong %s password accepted", prefix)
+		}
+	}
+}
+
+func TestVerifyAcceptsSHACrypt(t *testing.T) {
+	// Test vectors of the SHA-crypt specification
+	testCases := []struct {
+		hash, password string
+	}{
+		{"$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5", "Hello world!"},
+		{"$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA", "Hello world!"},
+		{"$5$rounds=5000$toolongsaltstrin$Un/5jzAHMgOGZ5.mWJpuVolil07guHPvOW8mGRcvxa5", "This is just a test"},
+		{"$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1", "Hello world!"},
+		{"$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.", "Hello world!"},
+	}
+	for _, tc := range testCases {
+		if !verify(tc.hash, tc.password) {
+			t.Errorf("%s: password rejected", tc.hash)
+		}
+		if verify(tc.hash, "wrong") {
+			t.Errorf("%s: wrong password accepted", tc.hash)
+		}
+	}
+}
+
+func TestParseHtpasswdSkipsUnsupportedHashes(t *testing.T) {
+	users := ParseHtpasswd([]byte("alice:$apr1$salt$hash\nbob:plain\ncarol:" + shaSecret + "\n"))
+
+	if len(users) != 1 || users["carol"] != shaSecret {
+		t.Errorf("Unexpected users %v", users)
+	}
+}
+
+func TestParseHtpasswdSkipsSHACryptHashesWithTooManyRounds(t *testing.T) {
+	content := "alice:$6$rounds=999999999$saltstring$hash\n" +
+		"bob:$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA\n"
+	users := ParseHtpasswd([]byte(content))
+
+	if _, ok := users["alice"]; ok || len(users) != 1 {
+		t.Errorf("Unexpected users %v", users)
+	}
+}
+
+func TestVerifyRejectsSHACryptHashesWithTooManyRounds(t *testing.T) {
+	if verify("$6$rounds=999999999$saltstring$hash", "Hello world!") {
+		t.Error("Hash with too many rounds accepted")
+	}
+}
+
+func TestVerifyRejectsUnsupportedHashes(t *testing.T) {
+	if verify("secret", "secret") {
+		t.Error("Plain text password accepted")
+	}
+	if verify("$apr1$salt$hash", "secret") {
+		t.Error("MD5 password accepted")
+	}
+}
+
+func TestAuthenticateReloadsTheFileWhenItChanges(t *testing.T) {
+	path, remove := writeHtpasswd(t, "alice:"+shaSecret+"\n")
+	defer remove()
+	h := &Htpasswd{file: watchedFile{path: path}}
+
+	if ok, err := h.Authenticate("alice", "secret"); !ok || err != nil {
+		t.Fatalf("alice rejected: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("bob:"+shaSecret+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ := h.Authenticate("alice", "secret"); ok {
+		t.Error("Removed user alice accepted")
+	}
+	if ok, _ := h.Authenticate("bob", "secret"); !ok {
+		t.Error("Added user bob rejected")
+	}
+}
+
+func TestAuthenticateFailsWhenTheFileIsMissing(t *testing.T) {
+	h := &Htpasswd{file: watchedFile{path: "/nonexistent/htpasswd"}}
+
+	if ok, err := h.Authenticate("alice", "secret"); ok || err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestBasicReturnsTheAuthenticatedUser(t *testing.T) {
+	path, remove := writeHtpasswd(t, "alice:"+shaSecret+"\n")
+	defer remove()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("alice", "secret")
+	w := httptest.NewRecorder()
+
+	user, ok := Basic(w, req, model.Auth{HtpasswdFile: path})
+
+	if !ok || user != "alice" {
+		t.Errorf("Unexpected result %q, %v", user, ok)
+	}
+}
+
+func TestBasic401sWithTheRealmOnWrongCredentials(t *testing.T) {
+	path, remove := writeHtpasswd(t, "alice:"+shaSecret+"\n")
+	defer remove()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("alice", "wrong")
+	w := httptest.NewRecorder()
+
+	_, ok := Basic(w, req, model.Auth{HtpasswdFile: path, Realm: "admins"})
+
+	res := w.Result()
+	if ok || res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", res.StatusCode)
+	}
+	if v := res.Header.Get("WWW-Authenticate"); v != `Basic realm="admins"` {
+		t.Errorf("Unexpected WWW-Authenticate %q", v)
+	}
+}
+
+func TestBasic401sWithTheDefaultRealmWithoutCredentials(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	_, ok := Basic(w, httptest.NewRequest("GET", "/", nil), model.Auth{HtpasswdFile: "/nonexistent"})
+
+	res := w.Result()
+	if ok || res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", res.StatusCode)
+	}
+	if v := res.Header.Get("WWW-Authenticate"); v != `Basic realm="kapow"` {
+		t.Errorf("Unexpected WWW-Authenticate %q", v)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	path, remove := writeHtpasswd(t, "")
+	defer remove()
+
+	testCases := []struct {
+		name  string
+		auth  *model.Auth
+		valid bool
+	}{
+		{"no auth", nil, true},
+		{"existing file", &model.Auth{HtpasswdFile: path}, true},
+		{"missing file name", &model.Auth{Realm: "admins"}, false},
+		{"missing file", &model.Auth{HtpasswdFile: "/nonexistent/htpasswd"}, false},
+	}
+	for _, tc := range testCases {
+		err := Validate(model.Route{Auth: tc.auth})
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: unexpected result %v", tc.name, err)
+		}
+	}
+}
+
+func TestValidateJWTAndClientCert(t *testing.T) {
+	keys, removeKeys := writeKeys(t, pemKey(t, newKey(t)))
+	defer removeKeys()
+	garbage, removeGarbage := writeKeys(t, "garbage")
+	defer removeGarbage()
+
+	testCases := []struct {
+		name  string
+		route model.Route
+		valid bool
+	}{
+		{"keys file", model.Route{JWT: &model.JWT{KeysFile: keys}}, true},
+		{"missing keys file name", model.Route{JWT: &model.JWT{Issuer: "me"}}, false},
+		{"missing keys file", model.Route{JWT: &model.JWT{KeysFile: "/nonexistent/keys"}}, false},
+		{"no keys", model.Route{JWT: &model.JWT{KeysFile: garbage}}, false},
+		{"with auth", model.Route{JWT: &model.JWT{KeysFile: keys}, Auth: &model.Auth{HtpasswdFile: keys}}, false},
+		{"client cert", model.Route{ClientCert: &model.ClientCert{CN: []string{"billing-.*"}}}, true},
+		{"invalid client cert", model.Route{ClientCert: &model.ClientCert{CN: []string{"("}}}, false},
+	}
+	for _, tc := range testCases {
+		err := Validate(tc.route)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: unexpected result %v", tc.name, err)
+		}
+	}
+}
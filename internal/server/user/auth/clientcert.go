@@ -0,0 +1,122 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// CertRules are the compiled client certificate rules of a Route
+type CertRules struct {
+	dn, cn, ou, san []*regexp.Regexp
+	// denyAll is set when the rules are invalid, so no certificate is let
+	// through by mistake
+	denyAll bool
+}
+
+// NewCertRules compiles the patterns of c.  When they are invalid the
+// returned rules reject every certificate.
+func NewCertRules(c model.ClientCert) *CertRules {
+	rules, err := compileCertRules(c)
+	if err != nil {
+		log.Printf("Invalid client certificate rules: %v\n", err)
+		return &CertRules{denyAll: true}
+	}
+	return rules
+}
+
+func compileCertRules(c model.ClientCert) (*CertRules, error) {
+	var rules CertRules
+	for _, field := range []struct {
+		name     string
+		patterns []string
+		compiled *[]*regexp.Regexp
+	}{
+		{"dn", c.DN, &rules.dn},
+		{"cn", c.CN, &rules.cn},
+		{"ou", c.OU, &rules.ou},
+		{"san", c.SAN, &rules.san},
+	} {
+		for _, p := range field.patterns {
+			re, err := regexp.Compile("^(?:" + p + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s pattern %q: %v", field.name, p, err)
+			}
+			*field.compiled = append(*field.compiled, re)
+		}
+	}
+	return &rules, nil
+}
+
+// Allows tells whether cert satisfies the rules: for every attribute with
+// patterns, some value of the attribute must match one of them
+func (rules *CertRules) Allows(cert *x509.Certificate) bool {
+	if rules.denyAll || cert == nil {
+		return false
+	}
+	var sans []string
+	sans = append(sans, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	return matchesAny(rules.dn, []string{cert.Subject.String()}) &&
+		matchesAny(rules.cn, []string{cert.Subject.CommonName}) &&
+		matchesAny(rules.ou, cert.Subject.OrganizationalUnit) &&
+		matchesAny(rules.san, sans)
+}
+
+// matchesAny tells whether some of values matches some of patterns, or there
+// are no patterns at all
+func matchesAny(patterns []*regexp.Regexp, values []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, re := range patterns {
+		for _, v := range values {
+			if re.MatchString(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClientCert checks the verified client certificate of req against rules.  It
+// answers 403 Forbidden and returns false when there is none or it doesn't
+// satisfy them.
+func ClientCert(w http.ResponseWriter, req *http.Request, rules *CertRules) bool {
+	var cert *x509.Certificate
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 && len(req.TLS.VerifiedChains[0]) > 0 {
+		cert = req.TLS.VerifiedChains[0][0]
+	}
+	if !rules.Allows(cert) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return false
+	}
+	return true
+}
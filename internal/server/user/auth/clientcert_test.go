@@ -0,0 +1,115 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func clientCert() *x509.Certificate {
+	spiffe, _ := url.Parse("spiffe://example.com/billing")
+	return &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         "billing-batch",
+			OrganizationalUnit: []string{"Billing", "Batch"},
+			Organization:       []string{"Example"},
+		},
+		DNSNames:       []string{"batch.billing.example.com"},
+		EmailAddresses: []string{"batch@example.com"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.7")},
+		URIs:           []*url.URL{spiffe},
+	}
+}
+
+func TestCertRulesAllows(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rules   model.ClientCert
+		allowed bool
+	}{
+		{"no rules", model.ClientCert{}, true},
+		{"matching dn", model.ClientCert{DN: []string{"CN=billing-batch,OU=.*,O=Example"}}, true},
+		{"matching cn", model.ClientCert{CN: []string{"reports", "billing-.*"}}, true},
+		{"partial cn", model.ClientCert{CN: []string{"billing"}}, false},
+		{"matching second ou", model.ClientCert{OU: []string{"Batch"}}, true},
+		{"wrong ou", model.ClientCert{OU: []string{"Sales"}}, false},
+		{"matching dns san", model.ClientCert{SAN: []string{`.*\.billing\.example\.com`}}, true},
+		{"matching email san", model.ClientCert{SAN: []string{"batch@example.com"}}, true},
+		{"matching ip san", model.ClientCert{SAN: []string{`10\.0\.0\.7`}}, true},
+		{"matching uri san", model.ClientCert{SAN: []string{"spiffe://example.com/.*"}}, true},
+		{"wrong san", model.ClientCert{SAN: []string{"other.example.com"}}, false},
+		{"all matching", model.ClientCert{CN: []string{"billing-batch"}, OU: []string{"Billing"}}, true},
+		{"one mismatching", model.ClientCert{CN: []string{"billing-batch"}, OU: []string{"Sales"}}, false},
+	}
+	for _, tc := range testCases {
+		if allowed := NewCertRules(tc.rules).Allows(clientCert()); allowed != tc.allowed {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.allowed, allowed)
+		}
+	}
+}
+
+func TestCertRulesRejectAMissingCertificate(t *testing.T) {
+	if NewCertRules(model.ClientCert{}).Allows(nil) {
+		t.Error("Missing certificate allowed")
+	}
+}
+
+func TestInvalidCertRulesRejectEveryCertificate(t *testing.T) {
+	if NewCertRules(model.ClientCert{CN: []string{"("}}).Allows(clientCert()) {
+		t.Error("Certificate allowed by invalid rules")
+	}
+}
+
+func TestClientCert403sOnMismatch(t *testing.T) {
+	testCases := []struct {
+		name  string
+		state *tls.ConnectionState
+	}{
+		{"plain http", nil},
+		{"no certificate", &tls.ConnectionState{}},
+		{"mismatching certificate", &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{clientCert()}}}},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.TLS = tc.state
+		w := httptest.NewRecorder()
+
+		ok := ClientCert(w, req, NewCertRules(model.ClientCert{CN: []string{"reports"}}))
+
+		if res := w.Result(); ok || res.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", tc.name, res.StatusCode)
+		}
+	}
+}
+
+func TestClientCertAcceptsAMatchingCertificate(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{clientCert()}}}
+
+	if !ClientCert(httptest.NewRecorder(), req, NewCertRules(model.ClientCert{CN: []string{"billing-batch"}})) {
+		t.Error("Matching certificate rejected")
+	}
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// now is the current time, used to check the validity period of the tokens
+var now = time.Now
+
+// KeySet is a JWKS or PEM file with the keys that sign the accepted tokens,
+// reloaded whenever it changes
+type KeySet struct {
+	m    sync.Mutex
+	file watchedFile
+	keys []jose.JSONWebKey
+	err  error
+}
+
+// keySetFiles holds the key set files by path, so the routes using the same
+// one share it
+var keySetFiles = struct {
+	sync.Mutex
+	byPath map[string]*KeySet
+}{byPath: map[string]*KeySet{}}
+
+// KeySetFile returns the key set file at path
+func KeySetFile(path string) *KeySet {
+	keySetFiles.Lock()
+	defer keySetFiles.Unlock()
+
+	ks, ok := keySetFiles.byPath[path]
+	if !ok {
+		ks = &KeySet{file: watchedFile{path: path}}
+		keySetFiles.byPath[path] = ks
+	}
+	return ks
+}
+
+// Keys returns the keys of the file, reading it again first if it changed
+// since the last time
+func (ks *KeySet) Keys() ([]jose.JSONWebKey, error) {
+	ks.m.Lock()
+	defer ks.m.Unlock()
+
+	content, err := ks.file.changes()
+	if err != nil {
+		return nil, err
+	}
+	if content != nil {
+		ks.keys, ks.err = ParseKeys(content)
+	}
+	return ks.keys, ks.err
+}
+
+// ParseKeys returns the public keys of a JWKS document or of a list of PEM
+// encoded public keys and certificates
+func ParseKeys(content []byte) ([]jose.JSONWebKey, error) {
+	var keys []jose.JSONWebKey
+
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		var jwks jose.JSONWebKeySet
+		if err := json.Unmarshal(content, &jwks); err != nil {
+			return nil, fmt.Errorf("invalid JWKS: %v", err)
+		}
+		keys = jwks.Keys
+	} else {
+		for {
+			var block *pem.Block
+			block, content = pem.Decode(content)
+			if block == nil {
+				break
+			}
+			key, err := parsePEMBlock(block)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, jose.JSONWebKey{Key: key})
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no keys found")
+	}
+	return keys, nil
+}
+
+func parsePEMBlock(block *pem.Block) (interface{}, error) {
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// VerifyToken checks the signature of token against the keys in the keys file
+// of j, and its issuer, audience and validity period against j.  Tokens
+// without an expiration time are rejected.  It returns the claims of
+// the token.
+func VerifyToken(token string, j model.JWT) (map[string]interface{}, error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := KeySetFile(j.KeysFile).Keys()
+	if err != nil {
+		log.Printf("Unable to read JWT keys file: %v\n", err)
+		return nil, err
+	}
+
+	kid := ""
+	if len(tok.Headers) > 0 {
+		kid = tok.Headers[0].KeyID
+	}
+	var std jwt.Claims
+	var claims map[string]interface{}
+	verified := false
+	for _, k := range keys {
+		if kid != "" && k.KeyID != "" && k.KeyID != kid {
+			continue
+		}
+		if err = tok.Claims(k.Key, &std, &claims); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("invalid signature")
+	}
+
+	// Validate only rejects expired tokens, not the ones that never expire
+	if std.Expiry == nil {
+		return nil, errors.New("missing exp claim")
+	}
+	expected := jwt.Expected{Issuer: j.Issuer, Time: now()}
+	if j.Audience != "" {
+		expected.Audience = jwt.Audience{j.Audience}
+	}
+	if err = std.Validate(expected); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Bearer authenticates req with the JWT bearer token in its Authorization
+// header.  It returns the claims of the token or, when it is missing or
+// invalid, answers 401 Unauthorized and returns false.
+func Bearer(w http.ResponseWriter, req *http.Request, j model.JWT) (map[string]interface{}, bool) {
+	challenge := fmt.Sprintf("Bearer realm=%q", DefaultRealm)
+
+	authorization := req.Header.Get("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		claims, err := VerifyToken(authorization[7:], j)
+		if err == nil {
+			return claims, true
+		}
+		challenge += `, error="invalid_token"`
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	return nil, false
+}
@@ -0,0 +1,278 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func pemKey(t *testing.T, key *ecdsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func jwksKey(t *testing.T, key *ecdsa.PrivateKey, kid string) string {
+	jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: kid, Algorithm: "ES256"}}}
+	content, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, kid string, claims interface{}) string {
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		opts = opts.WithHeader("kid", kid)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func writeKeys(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "kapow-jwt-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "keys")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":    "https://issuer.example.com",
+		"aud":    "kapow",
+		"sub":    "alice",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"groups": []string{"admins"},
+	}
+}
+
+func TestParseKeysAcceptsPEMAndJWKS(t *testing.T) {
+	key := newKey(t)
+
+	for name, content := range map[string]string{"PEM": pemKey(t, key), "JWKS": jwksKey(t, key, "k1")} {
+		keys, err := ParseKeys([]byte(content))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", name, err)
+		} else if len(keys) != 1 {
+			t.Errorf("%s: expected 1 key, got %d", name, len(keys))
+		}
+	}
+}
+
+func TestParseKeysRejectsFilesWithoutKeys(t *testing.T) {
+	for _, content := range []string{"", "garbage", `{"keys": []}`, "{"} {
+		if _, err := ParseKeys([]byte(content)); err == nil {
+			t.Errorf("Expected an error for %q", content)
+		}
+	}
+}
+
+func TestVerifyTokenReturnsTheClaims(t *testing.T) {
+	key := newKey(t)
+	path, remove := writeKeys(t, pemKey(t, key))
+	defer remove()
+
+	claims, err := VerifyToken(sign(t, key, "", validClaims()), model.JWT{KeysFile: path, Issuer: "https://issuer.example.com", Audience: "kapow"})
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if claims["sub"] != "alice" {
+		t.Errorf("Unexpected claims %v", claims)
+	}
+}
+
+func TestVerifyTokenRejectsTokensWithoutExpiration(t *testing.T) {
+	key := newKey(t)
+	path, remove := writeKeys(t, pemKey(t, key))
+	defer remove()
+	claims := validClaims()
+	delete(claims, "exp")
+
+	if _, err := VerifyToken(sign(t, key, "", claims), model.JWT{KeysFile: path}); err == nil {
+		t.Error("Token without exp accepted")
+	}
+}
+
+func TestVerifyTokenRejectsTokensOnceExpired(t *testing.T) {
+	key := newKey(t)
+	path, remove := writeKeys(t, pemKey(t, key))
+	defer remove()
+	token := sign(t, key, "", validClaims())
+	defer func() { now = time.Now }()
+
+	now = func() time.Time { return time.Now().Add(30 * time.Minute) }
+	if _, err := VerifyToken(token, model.JWT{KeysFile: path}); err != nil {
+		t.Errorf("Unexpected error before expiration: %v", err)
+	}
+
+	now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := VerifyToken(token, model.JWT{KeysFile: path}); err == nil {
+		t.Error("Token accepted after expiration")
+	}
+}
+
+func TestVerifyTokenSelectsTheKeyByKeyID(t *testing.T) {
+	key := newKey(t)
+	path, remove := writeKeys(t, jwksKey(t, key, "k1"))
+	defer remove()
+
+	if _, err := VerifyToken(sign(t, key, "k1", validClaims()), model.JWT{KeysFile: path}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if _, err := VerifyToken(sign(t, key, "k2", validClaims()), model.JWT{KeysFile: path}); err == nil {
+		t.Error("Token with an unknown key ID accepted")
+	}
+}
+
+func TestVerifyTokenRejectsInvalidTokens(t *testing.T) {
+	key, other := newKey(t), newKey(t)
+	path, remove := writeKeys(t, pemKey(t, key))
+	defer remove()
+	j := model.JWT{KeysFile: path, Issuer: "https://issuer.example.com", Audience: "kapow"}
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "https://evil.example.com"
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "other"
+
+	testCases := map[string]string{
+		"malformed":      "not.a.token",
+		"wrong key":      sign(t, other, "", validClaims()),
+		"expired":        sign(t, key, "", expired),
+		"wrong issuer":   sign(t, key, "", wrongIssuer),
+		"wrong audience": sign(t, key, "", wrongAudience),
+	}
+	for name, token := range testCases {
+		if _, err := VerifyToken(token, j); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+}
+
+func TestVerifyTokenReloadsTheKeysWhenTheyChange(t *testing.T) {
+	key, rotated := newKey(t), newKey(t)
+	path, remove := writeKeys(t, pemKey(t, key))
+	defer remove()
+	j := model.JWT{KeysFile: path}
+
+	if _, err := VerifyToken(sign(t, key, "", validClaims()), j); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(pemKey(t, rotated)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyToken(sign(t, key, "", validClaims()), j); err == nil {
+		t.Error("Token signed with the old key accepted")
+	}
+	if _, err := VerifyToken(sign(t, rotated, "", validClaims()), j); err != nil {
+		t.Errorf("Token signed with the new key rejected: %v", err)
+	}
+}
+
+func TestBearer401sOnInvalidTokens(t *testing.T) {
+	key := newKey(t)
+	path, remove := writeKeys(t, pemKey(t, key))
+	defer remove()
+
+	testCases := []struct {
+		name, authorization, challenge string
+	}{
+		{"missing", "", `Bearer realm="kapow"`},
+		{"basic", "Basic YWxpY2U6c2VjcmV0", `Bearer realm="kapow"`},
+		{"invalid", "Bearer not.a.token", `Bearer realm="kapow", error="invalid_token"`},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		w := httptest.NewRecorder()
+
+		_, ok := Bearer(w, req, model.JWT{KeysFile: path})
+
+		res := w.Result()
+		if ok || res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", tc.name, res.StatusCode)
+		}
+		if v := res.Header.Get("WWW-Authenticate"); v != tc.challenge {
+			t.Errorf("%s: unexpected WWW-Authenticate %q", tc.name, v)
+		}
+	}
+}
+
+func TestBearerReturnsTheClaims(t *testing.T) {
+	key := newKey(t)
+	path, remove := writeKeys(t, pemKey(t, key))
+	defer remove()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+sign(t, key, "", validClaims()))
+
+	claims, ok := Bearer(httptest.NewRecorder(), req, model.JWT{KeysFile: path})
+
+	if !ok || claims["sub"] != "alice" {
+		t.Errorf("Unexpected result %v, %v", claims, ok)
+	}
+}
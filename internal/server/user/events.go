@@ -0,0 +1,121 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package user
+
+import (
+	"reflect"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// EventBufferSize is the number of events a subscriber can fall behind
+// before being dropped
+var EventBufferSize = 64
+
+// Subscribe returns a channel where the events of every further change of
+// the list are sent, and a function to stop receiving them.  The channel is
+// closed when the subscription is cancelled or when the subscriber does not
+// keep up with the changes.
+func (srl *safeRouteList) Subscribe() (<-chan model.Event, func()) {
+	ch := make(chan model.Event, EventBufferSize)
+
+	srl.m.Lock()
+	if srl.subscribers == nil {
+		srl.subscribers = map[chan model.Event]struct{}{}
+	}
+	srl.subscribers[ch] = struct{}{}
+	srl.m.Unlock()
+
+	cancel := func() {
+		srl.m.Lock()
+		defer srl.m.Unlock()
+		if _, ok := srl.subscribers[ch]; ok {
+			delete(srl.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
+
+// publish sends to every subscriber the events describing the changes from
+// the list of routes prev to the current one.  It must be called while
+// holding the write lock.
+func (srl *safeRouteList) publish(prev []model.Route, rev model.Revision) {
+	if len(srl.subscribers) == 0 {
+		return
+	}
+
+	events := diff(prev, rev.Routes)
+	for i := range events {
+		events[i].Revision = rev.Number
+		events[i].Timestamp = rev.Timestamp
+	}
+
+	for ch := range srl.subscribers {
+		if !send(ch, events) {
+			// Slow subscriber, drop it instead of blocking every
+			// change of the list
+			delete(srl.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// send sends the given events to ch without blocking.  It returns false if
+// ch has no room for all of them.
+func send(ch chan model.Event, events []model.Event) bool {
+	for _, e := range events {
+		select {
+		case ch <- e:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// diff returns the events that turn the list of routes prev into next.
+// Routes are identified by ID, and a route whose Index changes only because
+// of other routes being added or deleted is not considered updated.
+func diff(prev, next []model.Route) []model.Event {
+	prevByID := make(map[string]model.Route, len(prev))
+	for _, r := range prev {
+		prevByID[r.ID] = r
+	}
+	nextIDs := make(map[string]bool, len(next))
+
+	events := []model.Event{}
+	for _, r := range next {
+		nextIDs[r.ID] = true
+		old, found := prevByID[r.ID]
+		if !found {
+			events = append(events, model.Event{Type: model.EventAdded, Route: r})
+			continue
+		}
+		old.Index = r.Index
+		if !reflect.DeepEqual(old, r) {
+			events = append(events, model.Event{Type: model.EventUpdated, Route: r})
+		}
+	}
+	for _, r := range prev {
+		if !nextIDs[r.ID] {
+			events = append(events, model.Event{Type: model.EventDeleted, Route: r})
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package user
+
+import (
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// receive returns the events already sent to ch
+func receive(ch <-chan model.Event) []model.Event {
+	es := []model.Event{}
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return es
+			}
+			es = append(es, e)
+		default:
+			return es
+		}
+	}
+}
+
+func TestSubscribeReceivesAnEventPerChange(t *testing.T) {
+	srl := New()
+	events, cancel := srl.Subscribe()
+	defer cancel()
+
+	srl.Append(model.Route{ID: "FOO", Command: "foo"})
+	_, _ = srl.Update("FOO", func(r *model.Route) error {
+		r.Command = "bar"
+		return nil
+	})
+	_ = srl.Delete("FOO")
+
+	es := receive(events)
+	if len(es) != 3 {
+		t.Fatalf("Expected 3 events, got %+v", es)
+	}
+	if es[0].Type != model.EventAdded || es[0].Revision != 1 || es[0].Route.Command != "foo" {
+		t.Errorf("Unexpected first event: %+v", es[0])
+	}
+	if es[1].Type != model.EventUpdated || es[1].Revision != 2 || es[1].Route.Command != "bar" {
+		t.Errorf("Unexpected second event: %+v", es[1])
+	}
+	if es[2].Type != model.EventDeleted || es[2].Revision != 3 || es[2].Route.ID != "FOO" {
+		t.Errorf("Unexpected third event: %+v", es[2])
+	}
+}
+
+func TestSubscribeIgnoresIndexShifts(t *testing.T) {
+	srl := New()
+	srl.Append(model.Route{ID: "FOO"})
+	events, cancel := srl.Subscribe()
+	defer cancel()
+
+	srl.Insert(model.Route{ID: "BAR", Index: 0})
+
+	es := receive(events)
+	if len(es) != 1 || es[0].Type != model.EventAdded || es[0].Route.ID != "BAR" {
+		t.Errorf("Unexpected events: %+v", es)
+	}
+}
+
+func TestSubscribeReceivesEveryChangeOfATransaction(t *testing.T) {
+	srl := New()
+	srl.Append(model.Route{ID: "FOO"})
+	events, cancel := srl.Subscribe()
+	defer cancel()
+
+	_, _ = srl.Transaction(func(rs []model.Route) ([]model.Route, error) {
+		return []model.Route{{ID: "BAR"}, {ID: "BAZ"}}, nil
+	})
+
+	es := receive(events)
+	if len(es) != 3 {
+		t.Fatalf("Expected 3 events, got %+v", es)
+	}
+	if es[0].Type != model.EventAdded || es[1].Type != model.EventAdded || es[2].Type != model.EventDeleted {
+		t.Errorf("Unexpected events: %+v", es)
+	}
+}
+
+func TestCancelClosesTheChannel(t *testing.T) {
+	srl := New()
+	events, cancel := srl.Subscribe()
+
+	cancel()
+	srl.Append(model.Route{ID: "FOO"})
+
+	if _, ok := <-events; ok {
+		t.Error("Channel not closed")
+	}
+	cancel()
+}
+
+func TestSlowSubscribersAreDropped(t *testing.T) {
+	orig := EventBufferSize
+	defer func() { EventBufferSize = orig }()
+	EventBufferSize = 1
+	srl := New()
+	events, cancel := srl.Subscribe()
+	defer cancel()
+
+	srl.Append(model.Route{ID: "FOO"})
+	srl.Append(model.Route{ID: "BAR"})
+
+	if es := receive(events); len(es) != 1 || es[0].Route.ID != "FOO" {
+		t.Errorf("Unexpected events: %+v", es)
+	}
+	if _, ok := <-events; ok {
+		t.Error("Channel not closed")
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package user
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user/mux"
+)
+
+// HistorySize is the maximum number of revisions kept by a safeRouteList
+var HistorySize = 100
+
+// ErrRevisionNotFound is returned when the requested revision is not in the
+// history, either because it never existed or because it is too old
+var ErrRevisionNotFound = errors.New("Revision not found")
+
+var now = time.Now
+
+// commit persists the current list of routes, records it as a new revision
+// and publishes its changes.  If it can't be persisted, the list is rolled back
+// to the last revision and ErrPersist is returned.  It must be called while
+// holding the write lock.
+func (srl *safeRouteList) commit(operation, routeID string) error {
+	rs := make([]model.Route, len(srl.rs))
+	copy(rs, srl.rs)
+	for i := 0; i < len(rs); i++ {
+		rs[i].Index = i
+	}
+
+	if err := srl.persist(rs); err != nil {
+		srl.rs = make([]model.Route, len(srl.committed))
+		copy(srl.rs, srl.committed)
+		return err
+	}
+
+	srl.revision++
+	rev := model.Revision{
+		Number:    srl.revision,
+		Timestamp: now(),
+		Operation: operation,
+		RouteID:   routeID,
+		Routes:    rs,
+	}
+	srl.history = append(srl.history, rev)
+	if len(srl.history) > HistorySize {
+		srl.history = srl.history[len(srl.history)-HistorySize:]
+	}
+
+	srl.publish(srl.committed, rev)
+	srl.committed = rs
+
+	return nil
+}
+
+// History returns the revisions kept, oldest first
+func (srl *safeRouteList) History() []model.Revision {
+	srl.m.RLock()
+	defer srl.m.RUnlock()
+
+	hs := make([]model.Revision, len(srl.history))
+	copy(hs, srl.history)
+	return hs
+}
+
+// Restore replaces the current list of routes with the one in the given
+// revision.  The restoration itself is recorded as a new revision.
+func (srl *safeRouteList) Restore(number int) ([]model.Route, error) {
+	srl.m.Lock()
+	var rs []model.Route
+	found := false
+	for _, h := range srl.history {
+		if h.Number == number {
+			rs = make([]model.Route, len(h.Routes))
+			copy(rs, h.Routes)
+			found = true
+			break
+		}
+	}
+	if !found {
+		srl.m.Unlock()
+		return nil, ErrRevisionNotFound
+	}
+	srl.rs = rs
+	if err := srl.commit("restore", ""); err != nil {
+		srl.m.Unlock()
+		return nil, err
+	}
+	srl.m.Unlock()
+
+	Server.Handler.(*mux.SwappableMux).Update(srl.Snapshot())
+
+	return srl.List(), nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package user
+
+import (
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func TestEveryChangeRecordsARevision(t *testing.T) {
+	srl := New()
+
+	r, _ := srl.Append(model.Route{ID: "FOO"})
+	srl.Insert(model.Route{ID: "BAR"})
+	_ = srl.Delete(r.ID)
+
+	hs := srl.History()
+	if len(hs) != 3 {
+		t.Fatalf("Expected 3 revisions, got %d", len(hs))
+	}
+	if hs[0].Number != 1 || hs[0].Operation != "append" || hs[0].RouteID != "FOO" {
+		t.Errorf("First revision mismatch: %+v", hs[0])
+	}
+	if hs[2].Number != 3 || hs[2].Operation != "delete" || len(hs[2].Routes) != 1 || hs[2].Routes[0].ID != "BAR" {
+		t.Errorf("Last revision mismatch: %+v", hs[2])
+	}
+}
+
+func TestHistoryIsBounded(t *testing.T) {
+	orig := HistorySize
+	defer func() { HistorySize = orig }()
+	HistorySize = 2
+	srl := New()
+
+	for i := 0; i < 5; i++ {
+		srl.Append(model.Route{})
+	}
+
+	hs := srl.History()
+	if len(hs) != 2 || hs[0].Number != 4 || hs[1].Number != 5 {
+		t.Errorf("History not bounded to the last revisions: %+v", hs)
+	}
+}
+
+func TestRestoreReturnsAnErrorWhenRevisionNotExists(t *testing.T) {
+	srl := New()
+
+	if _, err := srl.Restore(42); err != ErrRevisionNotFound {
+		t.Errorf("Expected ErrRevisionNotFound, got %v", err)
+	}
+}
+
+func TestRestoreBringsBackTheRoutesOfTheRevision(t *testing.T) {
+	srl := New()
+	srl.Append(model.Route{ID: "FOO"})
+	srl.Append(model.Route{ID: "BAR"})
+	_ = srl.Delete("FOO")
+
+	rs, err := srl.Restore(2)
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(rs) != 2 || rs[0].ID != "FOO" || rs[1].ID != "BAR" {
+		t.Errorf("Routes not restored: %+v", rs)
+	}
+	if hs := srl.History(); hs[len(hs)-1].Operation != "restore" || hs[len(hs)-1].Number != 4 {
+		t.Errorf("Restoration not recorded as a new revision: %+v", hs[len(hs)-1])
+	}
+}
+
+func TestRestoreCanGoBackToAnEmptyTable(t *testing.T) {
+	srl := New()
+	srl.Append(model.Route{ID: "FOO"})
+	_ = srl.Delete("FOO")
+	srl.Append(model.Route{ID: "BAR"})
+
+	rs, err := srl.Restore(2)
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(rs) != 0 || len(srl.rs) != 0 {
+		t.Errorf("Routes not restored: %+v", srl.rs)
+	}
+}
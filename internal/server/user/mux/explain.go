@@ -0,0 +1,76 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mux
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// Explain tells which of the routes in rs would handle req, in the same way
+// the mux built by Update for them does, along with the variables extracted
+// from the request and the earlier routes that only failed to match because
+// of its method
+func Explain(rs []model.Route, req *http.Request) model.Explanation {
+	m := gorillize(rs, func(model.Route) http.Handler { return http.NotFoundHandler() })
+
+	// gorillize skips the disabled routes unless they have to be answered,
+	// so this list follows the routes of m in the same order
+	served := []model.Route{}
+	for i, r := range rs {
+		if r.IsEnabled() || RespondDisabled {
+			r.Index = i
+			served = append(served, r)
+		}
+	}
+
+	var match mux.RouteMatch
+	matched := m.Match(req, &match) && match.MatchErr == nil
+
+	exp := model.Explanation{
+		Matches:          map[string]string{},
+		MethodMismatches: []model.Route{},
+	}
+	i, done := 0, false
+	_ = m.Walk(func(mr *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		r := served[i]
+		i++
+		if done {
+			return nil
+		}
+
+		if matched && mr == match.Route {
+			exp.Route = &r
+			if match.Vars != nil {
+				exp.Matches = match.Vars
+			}
+			done = true
+			return nil
+		}
+
+		var rm mux.RouteMatch
+		if !mr.Match(req, &rm) && rm.MatchErr == mux.ErrMethodMismatch {
+			exp.MethodMismatches = append(exp.MethodMismatches, r)
+		}
+		return nil
+	})
+
+	return exp
+}
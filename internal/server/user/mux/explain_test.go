@@ -0,0 +1,100 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mux
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func TestExplainReturnsTheWinningRouteAndItsMatches(t *testing.T) {
+	rs := []model.Route{
+		{ID: "GET_FOO", Method: "GET", Pattern: "/foo/{name}"},
+		{ID: "PUT_BAR", Method: "PUT", Pattern: "/bar/{name}"},
+		{ID: "POST_FOO", Method: "POST", Pattern: "/foo/{name}"},
+		{ID: "ANY_FOO", Method: "*", Pattern: "/foo/{name}"},
+	}
+	req := httptest.NewRequest("POST", "/foo/baz", nil)
+
+	exp := Explain(rs, req)
+
+	if exp.Route == nil {
+		t.Fatal("No route matched")
+	}
+	if exp.Route.ID != "POST_FOO" || exp.Route.Index != 2 {
+		t.Errorf("Route mismatch. Expected: POST_FOO at 2, got: %s at %d", exp.Route.ID, exp.Route.Index)
+	}
+	if !reflect.DeepEqual(exp.Matches, map[string]string{"name": "baz"}) {
+		t.Errorf("Matches mismatch. Expected: name=baz, got: %v", exp.Matches)
+	}
+	if len(exp.MethodMismatches) != 1 || exp.MethodMismatches[0].ID != "GET_FOO" {
+		t.Errorf("Method mismatches mismatch. Expected: [GET_FOO], got: %v", exp.MethodMismatches)
+	}
+}
+
+func TestExplainReportsMethodMismatchesWhenNoRouteMatches(t *testing.T) {
+	rs := []model.Route{
+		{ID: "GET_FOO", Method: "GET", Pattern: "/foo"},
+		{ID: "GET_BAR", Method: "GET", Pattern: "/bar"},
+		{ID: "PUT_FOO", Method: "PUT", Pattern: "/foo"},
+	}
+	req := httptest.NewRequest("POST", "/foo", nil)
+
+	exp := Explain(rs, req)
+
+	if exp.Route != nil {
+		t.Errorf("Unexpected route matched: %s", exp.Route.ID)
+	}
+	if len(exp.MethodMismatches) != 2 || exp.MethodMismatches[0].ID != "GET_FOO" || exp.MethodMismatches[1].ID != "PUT_FOO" {
+		t.Errorf("Method mismatches mismatch. Expected: [GET_FOO PUT_FOO], got: %v", exp.MethodMismatches)
+	}
+}
+
+func TestExplainSkipsDisabledRoutes(t *testing.T) {
+	disabled := false
+	rs := []model.Route{
+		{ID: "OFF", Method: "GET", Pattern: "/foo", Enabled: &disabled},
+		{ID: "ON", Method: "GET", Pattern: "/foo"},
+	}
+	req := httptest.NewRequest("GET", "/foo", nil)
+
+	exp := Explain(rs, req)
+
+	if exp.Route == nil || exp.Route.ID != "ON" || exp.Route.Index != 1 {
+		t.Errorf("Route mismatch. Expected: ON at 1, got: %+v", exp.Route)
+	}
+}
+
+func TestExplainReportsDisabledRoutesWhenTheyAreAnswered(t *testing.T) {
+	RespondDisabled = true
+	defer func() { RespondDisabled = false }()
+	disabled := false
+	rs := []model.Route{
+		{ID: "OFF", Method: "GET", Pattern: "/foo", Enabled: &disabled},
+		{ID: "ON", Method: "GET", Pattern: "/foo"},
+	}
+	req := httptest.NewRequest("GET", "/foo", nil)
+
+	exp := Explain(rs, req)
+
+	if exp.Route == nil || exp.Route.ID != "OFF" || exp.Route.Index != 0 {
+		t.Errorf("Route mismatch. Expected: OFF at 0, got: %+v", exp.Route)
+	}
+}
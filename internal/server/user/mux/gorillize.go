@@ -24,12 +24,34 @@ import (
 	"github.com/BBVA/kapow/internal/server/model"
 )
 
+// RespondDisabled makes the disabled routes answer their requests with 503
+// Service Unavailable, instead of being skipped as if they didn't exist
+var RespondDisabled = false
+
 func gorillize(rs []model.Route, buildHandler func(model.Route) http.Handler) *mux.Router {
 	m := mux.NewRouter()
 
 	for _, r := range rs {
-		m.Handle(r.Pattern, buildHandler(r)).Methods(r.Method)
+		var h http.Handler
+		if r.IsEnabled() {
+			h = buildHandler(r)
+		} else if RespondDisabled {
+			h = http.HandlerFunc(unavailable)
+		} else {
+			continue
+		}
+
+		mr := m.Handle(r.Pattern, h)
+		if ms := Methods(r.Method); ms != nil {
+			mr = mr.Methods(ms...)
+		}
+		matchers(mr, r)
 	}
 
 	return m
 }
+
+// unavailable answers the requests to disabled routes
+func unavailable(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
@@ -182,3 +182,166 @@ func TestGorillizeReturnsAMuxThatRespectsRouteOrderBA(t *testing.T) {
 		t.Errorf("Mux did not respect route order %q", body)
 	}
 }
+
+func TestGorillizeReturnsAMuxThatMatchesByHeaders(t *testing.T) {
+	rs := []model.Route{
+		{
+			ID:      "json",
+			Pattern: "/foo",
+			Method:  "POST",
+			Headers: map[string]string{"Content-Type": "^application/json"},
+		},
+		{
+			ID:      "form",
+			Pattern: "/foo",
+			Method:  "POST",
+		},
+	}
+	m := *gorillize(rs, handleRouteIDToBody)
+
+	req := httptest.NewRequest("POST", "/foo", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, req)
+	if body, _ := ioutil.ReadAll(w.Result().Body); string(body) != "json" {
+		t.Errorf("Request with matching header routed to %q", body)
+	}
+
+	req = httptest.NewRequest("POST", "/foo", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, req)
+	if body, _ := ioutil.ReadAll(w.Result().Body); string(body) != "form" {
+		t.Errorf("Request without matching header routed to %q", body)
+	}
+}
+
+func TestGorillizeReturnsAMuxThatMatchesByHostQueriesAndSchemes(t *testing.T) {
+	rs := []model.Route{
+		{
+			ID:      "routeA",
+			Pattern: "/foo",
+			Method:  "GET",
+			Host:    "{sub}.example.com",
+			Queries: map[string]string{"format": "{format:json|xml}"},
+			Schemes: []string{"http"},
+		},
+	}
+	m := *gorillize(rs, handleRouteIDToBody)
+
+	req := httptest.NewRequest("GET", "http://api.example.com/foo?format=xml", nil)
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, req)
+	if body, _ := ioutil.ReadAll(w.Result().Body); string(body) != "routeA" {
+		t.Errorf("Matching request routed to %q", body)
+	}
+
+	for _, url := range []string{
+		"http://example.org/foo?format=xml",
+		"http://api.example.com/foo?format=csv",
+		"https://api.example.com/foo?format=xml",
+	} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, req)
+		if w.Result().StatusCode != http.StatusNotFound {
+			t.Errorf("Request to %s unexpectedly matched", url)
+		}
+	}
+}
+
+func TestGorillizeReturnsAMuxThatMatchesEveryListedMethod(t *testing.T) {
+	rs := []model.Route{
+		{
+			Pattern: "/foo",
+			Method:  "GET, HEAD",
+		},
+	}
+	m := *gorillize(rs, handlerStatusOK)
+
+	for method, status := range map[string]int{
+		"GET":  http.StatusOK,
+		"HEAD": http.StatusOK,
+		"POST": http.StatusMethodNotAllowed,
+	} {
+		req := httptest.NewRequest(method, "/foo", nil)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, req)
+		if w.Result().StatusCode != status {
+			t.Errorf("status mismatch for %s, got %d, want %d", method, w.Result().StatusCode, status)
+		}
+	}
+}
+
+func TestGorillizeReturnsAMuxThatMatchesAnyMethod(t *testing.T) {
+	rs := []model.Route{
+		{
+			Pattern: "/foo",
+			Method:  model.AnyMethod,
+		},
+	}
+	m := *gorillize(rs, handlerStatusOK)
+
+	for _, method := range []string{"GET", "POST", "UNORTHODOX"} {
+		req := httptest.NewRequest(method, "/foo", nil)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, req)
+		if w.Result().StatusCode != http.StatusOK {
+			t.Errorf("status mismatch for %s, got %d, want 200", method, w.Result().StatusCode)
+		}
+	}
+}
+
+func TestGorillizeReturnsAMuxThatSkipsDisabledRoutes(t *testing.T) {
+	disabled := false
+	rs := []model.Route{
+		{
+			ID:      "routeA",
+			Pattern: "/foo",
+			Method:  "GET",
+			Enabled: &disabled,
+		},
+		{
+			ID:      "routeB",
+			Pattern: "/foo",
+			Method:  "GET",
+		},
+	}
+	m := *gorillize(rs, handleRouteIDToBody)
+
+	req := httptest.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, req)
+
+	if body, _ := ioutil.ReadAll(w.Result().Body); string(body) != "routeB" {
+		t.Errorf("Disabled route not skipped, got %q", body)
+	}
+}
+
+func TestGorillizeReturnsAMuxThat503sOnDisabledRoutesWhenRespondDisabled(t *testing.T) {
+	defer func() { RespondDisabled = false }()
+	RespondDisabled = true
+	disabled := false
+	rs := []model.Route{
+		{
+			ID:      "routeA",
+			Pattern: "/foo",
+			Method:  "GET",
+			Enabled: &disabled,
+		},
+		{
+			ID:      "routeB",
+			Pattern: "/foo",
+			Method:  "GET",
+		},
+	}
+	m := *gorillize(rs, handleRouteIDToBody)
+
+	req := httptest.NewRequest("GET", "/foo", nil)
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, req)
+
+	if w.Result().StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status mismatch, got %d, want 503", w.Result().StatusCode)
+	}
+}
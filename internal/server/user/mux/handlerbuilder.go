@@ -19,14 +19,17 @@ package mux
 import (
 	"bufio"
 	"bytes"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/google/uuid"
 
 	"github.com/BBVA/kapow/internal/logger"
 	"github.com/BBVA/kapow/internal/server/data"
 	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user/auth"
 	"github.com/BBVA/kapow/internal/server/user/spawn"
 )
 
@@ -34,7 +37,44 @@ var spawner = spawn.Spawn
 var idGenerator = uuid.NewUUID
 
 func handlerBuilder(route model.Route) http.Handler {
+	rl := rateLimiterFor(route)
+	lim := limiterFor(route)
+	var certRules *auth.CertRules
+	if route.ClientCert != nil {
+		certRules = auth.NewCertRules(*route.ClientCert)
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rl != nil && !rl.limit(w, r) {
+			return
+		}
+		if certRules != nil && !auth.ClientCert(w, r, certRules) {
+			return
+		}
+		var authUser string
+		if route.Auth != nil {
+			user, ok := auth.Basic(w, r, *route.Auth)
+			if !ok {
+				return
+			}
+			authUser = user
+		}
+		var claims map[string]interface{}
+		if route.JWT != nil {
+			c, ok := auth.Bearer(w, r, *route.JWT)
+			if !ok {
+				return
+			}
+			claims = c
+		}
+		if lim != nil {
+			if err := lim.acquire(r.Context().Done()); err != nil {
+				log.Printf("Route %s: %v\n", route.ID, err)
+				unavailableBusy(w, lim)
+				return
+			}
+			defer lim.release()
+		}
+
 		id, err := idGenerator()
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -47,6 +87,18 @@ func handlerBuilder(route model.Route) http.Handler {
 			Request: r,
 			Writer:  w,
 		}
+		h.AuthUser = authUser
+		h.JWTClaims = claims
+
+		if route.TmpDir {
+			h.TmpDir, err = ioutil.TempDir("", "kapow-"+h.ID+"-")
+			if err != nil {
+				log.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			defer os.RemoveAll(h.TmpDir)
+		}
 
 		data.Handlers.Add(h)
 		defer data.Handlers.Remove(h.ID)
@@ -56,7 +108,12 @@ func handlerBuilder(route model.Route) http.Handler {
 		err = spawner(h, stdOut, stdErr)
 		//err = spawner(h, nil)
 
-		if err != nil {
+		if err == spawn.ErrTimeout {
+			log.Printf("Handler %s: %v, process group terminated\n", h.ID, err)
+			respondTimeout(h)
+		} else if err == spawn.ErrDisconnected {
+			log.Printf("Handler %s: %v\n", h.ID, err)
+		} else if err != nil {
 			log.Println(err)
 		}
 
@@ -64,6 +121,18 @@ func handlerBuilder(route model.Route) http.Handler {
 	})
 }
 
+// respondTimeout answers with 504 Gateway Timeout the request of h, unless its
+// response has already been started
+func respondTimeout(h *model.Handler) {
+	h.Writing.Lock()
+	defer h.Writing.Unlock()
+
+	if !h.Sent {
+		h.Writer.WriteHeader(http.StatusGatewayTimeout)
+		h.Sent = true
+	}
+}
+
 func createLogMsg(handlerId string, stdout, stderr bytes.Buffer) logger.LogMsg {
 	var messages []string
 	scanner := bufio.NewScanner(bytes.NewBuffer(stdout.Bytes()))
@@ -20,8 +20,10 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -199,6 +201,179 @@ func TestHandlerBuilderRemovesHandlerWhenDone(t *testing.T) {
 	}
 }
 
+func TestHandlerBuilderCreatesAndRemovesThePrivateTmpDir(t *testing.T) {
+	data.Handlers = data.New()
+	route := model.Route{TmpDir: true}
+	idGenerator = uuid.NewUUID
+	var tmpDir string
+	existed := false
+	defer func() { spawner = spawn.Spawn }()
+	spawner = func(h *model.Handler, out io.Writer, er io.Writer) error {
+		tmpDir = h.TmpDir
+		fi, err := os.Stat(tmpDir)
+		existed = err == nil && fi.IsDir()
+		return nil
+	}
+
+	handlerBuilder(route).ServeHTTP(nil, nil)
+
+	if !existed {
+		t.Fatalf("Private tmp dir %q not created", tmpDir)
+	}
+	if _, err := os.Stat(tmpDir); !os.IsNotExist(err) {
+		t.Errorf("Private tmp dir %q not removed", tmpDir)
+	}
+}
+
+func TestHandlerBuilderDoesNotCreateATmpDirUnlessAsked(t *testing.T) {
+	data.Handlers = data.New()
+	idGenerator = uuid.NewUUID
+	tmpDir := "unset"
+	defer func() { spawner = spawn.Spawn }()
+	spawner = func(h *model.Handler, out io.Writer, er io.Writer) error {
+		tmpDir = h.TmpDir
+		return nil
+	}
+
+	handlerBuilder(model.Route{}).ServeHTTP(nil, nil)
+
+	if tmpDir != "" {
+		t.Errorf("Unexpected tmp dir %q", tmpDir)
+	}
+}
+
+func TestHandlerBuilder401sWithoutSpawningOnWrongCredentials(t *testing.T) {
+	data.Handlers = data.New()
+	idGenerator = uuid.NewUUID
+	called := false
+	defer func() { spawner = spawn.Spawn }()
+	spawner = func(h *model.Handler, out io.Writer, er io.Writer) error {
+		called = true
+		return nil
+	}
+	route := model.Route{Auth: &model.Auth{HtpasswdFile: "/nonexistent/htpasswd"}}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("alice", "secret")
+	w := httptest.NewRecorder()
+
+	handlerBuilder(route).ServeHTTP(w, req)
+
+	if called {
+		t.Error("Spawner called")
+	}
+	if res := w.Result(); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", res.StatusCode)
+	}
+}
+
+func TestHandlerBuilder401sWithoutSpawningOnInvalidTokens(t *testing.T) {
+	data.Handlers = data.New()
+	idGenerator = uuid.NewUUID
+	called := false
+	defer func() { spawner = spawn.Spawn }()
+	spawner = func(h *model.Handler, out io.Writer, er io.Writer) error {
+		called = true
+		return nil
+	}
+	route := model.Route{JWT: &model.JWT{KeysFile: "/nonexistent/keys"}}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer not.a.token")
+	w := httptest.NewRecorder()
+
+	handlerBuilder(route).ServeHTTP(w, req)
+
+	if called {
+		t.Error("Spawner called")
+	}
+	if res := w.Result(); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", res.StatusCode)
+	}
+}
+
+func TestHandlerBuilder403sWithoutSpawningWithoutAClientCert(t *testing.T) {
+	data.Handlers = data.New()
+	idGenerator = uuid.NewUUID
+	called := false
+	defer func() { spawner = spawn.Spawn }()
+	spawner = func(h *model.Handler, out io.Writer, er io.Writer) error {
+		called = true
+		return nil
+	}
+	route := model.Route{ClientCert: &model.ClientCert{CN: []string{"billing-.*"}}}
+	w := httptest.NewRecorder()
+
+	handlerBuilder(route).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if called {
+		t.Error("Spawner called")
+	}
+	if res := w.Result(); res.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403, got %d", res.StatusCode)
+	}
+}
+
+func TestHandlerBuilderExposesTheAuthenticatedUser(t *testing.T) {
+	data.Handlers = data.New()
+	idGenerator = uuid.NewUUID
+	f, err := ioutil.TempFile("", "kapow-htpasswd-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	// "{SHA}" + base64(sha1("secret"))
+	_, _ = f.WriteString("alice:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=\n")
+	f.Close()
+	var user string
+	defer func() { spawner = spawn.Spawn }()
+	spawner = func(h *model.Handler, out io.Writer, er io.Writer) error {
+		user = h.AuthUser
+		return nil
+	}
+	route := model.Route{Auth: &model.Auth{HtpasswdFile: f.Name()}}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("alice", "secret")
+
+	handlerBuilder(route).ServeHTTP(httptest.NewRecorder(), req)
+
+	if user != "alice" {
+		t.Errorf("Expected user alice, got %q", user)
+	}
+}
+
+func TestHandlerBuilder504sWhenTheSpawnTimesOut(t *testing.T) {
+	data.Handlers = data.New()
+	idGenerator = uuid.NewUUID
+	defer func() { spawner = spawn.Spawn }()
+	spawner = func(h *model.Handler, out io.Writer, er io.Writer) error {
+		return spawn.ErrTimeout
+	}
+	w := httptest.NewRecorder()
+
+	handlerBuilder(model.Route{}).ServeHTTP(w, nil)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("Status code mismatch. Expected: %d, got: %d", http.StatusGatewayTimeout, w.Code)
+	}
+}
+
+func TestHandlerBuilderKeepsTheSentResponseWhenTheSpawnTimesOut(t *testing.T) {
+	data.Handlers = data.New()
+	idGenerator = uuid.NewUUID
+	defer func() { spawner = spawn.Spawn }()
+	spawner = func(h *model.Handler, out io.Writer, er io.Writer) error {
+		h.Writer.WriteHeader(http.StatusTeapot)
+		h.Sent = true
+		return spawn.ErrTimeout
+	}
+	w := httptest.NewRecorder()
+
+	handlerBuilder(model.Route{}).ServeHTTP(w, nil)
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("Status code mismatch. Expected: %d, got: %d", http.StatusTeapot, w.Code)
+	}
+}
+
 func TestCreateLogMsgAdsPrefixInfo(t *testing.T) {
 	expected := "FOO"
 
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+var (
+	// errQueueFull is returned when a request can't wait for its turn
+	// because the queue of its route is full
+	errQueueFull = errors.New("Queue full")
+	// errQueueTimeout is returned when a request waited for its turn
+	// longer than the queue timeout of its route
+	errQueueTimeout = errors.New("Queue wait timed out")
+	// errClientGone is returned when the client went away while waiting
+	errClientGone = errors.New("Client gone while queued")
+)
+
+// limiter bounds the number of requests of a route served at the same time,
+// making up to maxQueue of the exceeding ones wait for their turn
+type limiter struct {
+	slots    chan struct{}
+	maxQueue int
+	timeout  time.Duration
+
+	m      sync.Mutex
+	queued int
+}
+
+// limiters holds the limiter of each route by its id.  They outlive the
+// handlers built by gorillize, so the limits hold across route table updates.
+var limiters = struct {
+	sync.Mutex
+	byID map[string]*limiter
+}{byID: map[string]*limiter{}}
+
+// limiterFor returns the limiter of r, or nil if r has no concurrency limit.
+// The current limiter of the route is reused as long as its limits are the
+// same.
+func limiterFor(r model.Route) *limiter {
+	if r.MaxConcurrency <= 0 {
+		return nil
+	}
+	timeout, _ := queueTimeout(r)
+
+	limiters.Lock()
+	defer limiters.Unlock()
+
+	l, ok := limiters.byID[r.ID]
+	if !ok || cap(l.slots) != r.MaxConcurrency || l.maxQueue != r.MaxQueue || l.timeout != timeout {
+		l = &limiter{
+			slots:    make(chan struct{}, r.MaxConcurrency),
+			maxQueue: r.MaxQueue,
+			timeout:  timeout,
+		}
+		limiters.byID[r.ID] = l
+	}
+	return l
+}
+
+// pruneLimiters forgets the limiters and rate limiters of the routes not in rs
+func pruneLimiters(rs []model.Route) {
+	ids := make(map[string]bool, len(rs))
+	for _, r := range rs {
+		ids[r.ID] = true
+	}
+
+	limiters.Lock()
+	for id := range limiters.byID {
+		if !ids[id] {
+			delete(limiters.byID, id)
+		}
+	}
+	limiters.Unlock()
+
+	rateLimiters.Lock()
+	for id := range rateLimiters.byID {
+		if !ids[id] {
+			delete(rateLimiters.byID, id)
+		}
+	}
+	rateLimiters.Unlock()
+}
+
+// acquire takes a slot for a request, waiting in the queue if there is none
+// free.  done is closed when the client goes away.
+func (l *limiter) acquire(done <-chan struct{}) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	l.m.Lock()
+	if l.queued >= l.maxQueue {
+		l.m.Unlock()
+		return errQueueFull
+	}
+	l.queued++
+	l.m.Unlock()
+	defer func() {
+		l.m.Lock()
+		l.queued--
+		l.m.Unlock()
+	}()
+
+	var expired <-chan time.Time
+	if l.timeout > 0 {
+		timer := time.NewTimer(l.timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-expired:
+		return errQueueTimeout
+	case <-done:
+		return errClientGone
+	}
+}
+
+// release frees the slot taken by acquire
+func (l *limiter) release() {
+	<-l.slots
+}
+
+// retryAfter is the value of the Retry-After header of the requests rejected
+// by l, in seconds
+func (l *limiter) retryAfter() string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(l.timeout.Seconds()))))
+}
+
+// queueTimeout returns the maximum time the requests of r wait in its queue.
+// Zero means no limit.
+func queueTimeout(r model.Route) (time.Duration, error) {
+	if r.QueueTimeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(r.QueueTimeout)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid queue timeout %q", r.QueueTimeout)
+	}
+	return d, nil
+}
+
+// ValidateLimits checks that the concurrency and rate limits of r are
+// consistent
+func ValidateLimits(r model.Route) error {
+	if r.RateLimit != nil {
+		if err := validateRateLimit(*r.RateLimit); err != nil {
+			return err
+		}
+	}
+	if r.MaxConcurrency < 0 {
+		return fmt.Errorf("negative max_concurrency %d", r.MaxConcurrency)
+	}
+	if r.MaxQueue < 0 {
+		return fmt.Errorf("negative max_queue %d", r.MaxQueue)
+	}
+	if r.MaxQueue > 0 && r.MaxConcurrency == 0 {
+		return errors.New("max_queue requires max_concurrency")
+	}
+	_, err := queueTimeout(r)
+	return err
+}
+
+// unavailableBusy answers the requests rejected by l
+func unavailableBusy(w http.ResponseWriter, l *limiter) {
+	w.Header().Set("Retry-After", l.retryAfter())
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mux
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/BBVA/kapow/internal/server/data"
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user/spawn"
+)
+
+func TestLimiterRejectsWhenTheQueueIsFull(t *testing.T) {
+	l := limiterFor(model.Route{ID: "LIMIT_FULL", MaxConcurrency: 1})
+	defer pruneLimiters(nil)
+
+	if err := l.acquire(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := l.acquire(nil); err != errQueueFull {
+		t.Errorf("Error mismatch. Expected: %v, got: %v", errQueueFull, err)
+	}
+}
+
+func TestLimiterQueuesUntilASlotIsReleased(t *testing.T) {
+	l := limiterFor(model.Route{ID: "LIMIT_QUEUE", MaxConcurrency: 1, MaxQueue: 1})
+	defer pruneLimiters(nil)
+	_ = l.acquire(nil)
+	time.AfterFunc(50*time.Millisecond, l.release)
+
+	if err := l.acquire(nil); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestLimiterGivesUpAfterTheQueueTimeout(t *testing.T) {
+	l := limiterFor(model.Route{ID: "LIMIT_TIMEOUT", MaxConcurrency: 1, MaxQueue: 1, QueueTimeout: "50ms"})
+	defer pruneLimiters(nil)
+	_ = l.acquire(nil)
+
+	if err := l.acquire(nil); err != errQueueTimeout {
+		t.Errorf("Error mismatch. Expected: %v, got: %v", errQueueTimeout, err)
+	}
+	if l.queued != 0 {
+		t.Errorf("Queue not emptied, %d requests left", l.queued)
+	}
+}
+
+func TestLimiterStopsWaitingWhenTheClientGoesAway(t *testing.T) {
+	l := limiterFor(model.Route{ID: "LIMIT_GONE", MaxConcurrency: 1, MaxQueue: 1})
+	defer pruneLimiters(nil)
+	_ = l.acquire(nil)
+	done := make(chan struct{})
+	close(done)
+
+	if err := l.acquire(done); err != errClientGone {
+		t.Errorf("Error mismatch. Expected: %v, got: %v", errClientGone, err)
+	}
+}
+
+func TestLimiterForKeepsTheLimiterWhileTheLimitsAreTheSame(t *testing.T) {
+	defer pruneLimiters(nil)
+	r := model.Route{ID: "LIMIT_KEEP", MaxConcurrency: 2}
+
+	first := limiterFor(r)
+	if limiterFor(r) != first {
+		t.Error("Limiter not reused")
+	}
+	r.MaxConcurrency = 3
+	if limiterFor(r) == first {
+		t.Error("Limiter not replaced when its limits changed")
+	}
+	if limiterFor(model.Route{ID: "NO_LIMIT"}) != nil {
+		t.Error("Limiter created for a route without limits")
+	}
+}
+
+func TestPruneLimitersForgetsTheRemovedRoutes(t *testing.T) {
+	defer pruneLimiters(nil)
+	limiterFor(model.Route{ID: "KEPT", MaxConcurrency: 1})
+	limiterFor(model.Route{ID: "REMOVED", MaxConcurrency: 1})
+
+	pruneLimiters([]model.Route{{ID: "KEPT"}})
+
+	if _, ok := limiters.byID["KEPT"]; !ok {
+		t.Error("Limiter of an existing route removed")
+	}
+	if _, ok := limiters.byID["REMOVED"]; ok {
+		t.Error("Limiter of a removed route kept")
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	testCases := []struct {
+		route model.Route
+		valid bool
+	}{
+		{model.Route{}, true},
+		{model.Route{MaxConcurrency: 2, MaxQueue: 10, QueueTimeout: "5s"}, true},
+		{model.Route{MaxConcurrency: -1}, false},
+		{model.Route{MaxConcurrency: 1, MaxQueue: -1}, false},
+		{model.Route{MaxQueue: 1}, false},
+		{model.Route{MaxConcurrency: 1, QueueTimeout: "soon"}, false},
+	}
+
+	for _, tc := range testCases {
+		if err := ValidateLimits(tc.route); (err == nil) != tc.valid {
+			t.Errorf("ValidateLimits(%+v) = %v, want valid %v", tc.route, err, tc.valid)
+		}
+	}
+}
+
+func TestHandlerBuilder503sWhenTheRouteIsBusy(t *testing.T) {
+	data.Handlers = data.New()
+	idGenerator = uuid.NewUUID
+	defer pruneLimiters(nil)
+	defer func() { spawner = spawn.Spawn }()
+	started, finish := make(chan struct{}), make(chan struct{})
+	spawner = func(h *model.Handler, out io.Writer, er io.Writer) error {
+		close(started)
+		<-finish
+		return nil
+	}
+	h := handlerBuilder(model.Route{ID: "BUSY", MaxConcurrency: 1, QueueTimeout: "3s"})
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	<-started
+	defer close(finish)
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Status code mismatch. Expected: %d, got: %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if v := w.Header().Get("Retry-After"); v != "3" {
+		t.Errorf("Retry-After mismatch. Expected: %q, got: %q", "3", v)
+	}
+}
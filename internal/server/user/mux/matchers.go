@@ -0,0 +1,123 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mux
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// matchers adds to mr the optional matchers of r: host, headers, queries and
+// schemes
+func matchers(mr *mux.Route, r model.Route) *mux.Route {
+	if r.Host != "" {
+		mr = mr.Host(r.Host)
+	}
+	if len(r.Headers) > 0 {
+		mr = mr.HeadersRegexp(pairs(r.Headers)...)
+	}
+	if len(r.Queries) > 0 {
+		mr = mr.Queries(pairs(r.Queries)...)
+	}
+	if len(r.Schemes) > 0 {
+		mr = mr.Schemes(r.Schemes...)
+	}
+	return mr
+}
+
+// Methods returns the HTTP methods listed in method, a comma separated list
+// of them.  A nil list is returned when any method is accepted, that is, when
+// the list includes model.AnyMethod.
+func Methods(method string) []string {
+	ms := []string{}
+	for _, m := range strings.Split(method, ",") {
+		m = strings.TrimSpace(m)
+		if m == model.AnyMethod {
+			return nil
+		}
+		if m != "" {
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+// NormalizeMethod returns method, a comma separated list of HTTP methods, in
+// its canonical form: upper case, sorted and without duplicates, or just
+// model.AnyMethod when it accepts any method.  Two routes accept the same
+// methods if and only if their normalized methods are equal.
+func NormalizeMethod(method string) string {
+	ms := Methods(strings.ToUpper(method))
+	if ms == nil {
+		return model.AnyMethod
+	}
+	sort.Strings(ms)
+	unique := ms[:0]
+	for i, m := range ms {
+		if i == 0 || m != ms[i-1] {
+			unique = append(unique, m)
+		}
+	}
+	return strings.Join(unique, ",")
+}
+
+// ValidateMatchers checks that the methods and the optional matchers of r
+// comply with the gorilla mux requirements
+func ValidateMatchers(r model.Route) error {
+	for _, m := range strings.Split(r.Method, ",") {
+		if strings.TrimSpace(m) == "" {
+			return fmt.Errorf("empty method in %q", r.Method)
+		}
+	}
+	for _, s := range r.Schemes {
+		if s := strings.ToLower(s); s != "http" && s != "https" {
+			return fmt.Errorf("invalid scheme %q", s)
+		}
+	}
+	for k := range r.Headers {
+		if k == "" {
+			return fmt.Errorf("empty header name")
+		}
+	}
+	for k := range r.Queries {
+		if k == "" {
+			return fmt.Errorf("empty query parameter name")
+		}
+	}
+
+	return matchers(mux.NewRouter().NewRoute().BuildOnly(), r).GetError()
+}
+
+// pairs flattens m into a list of keys and values, sorted by key
+func pairs(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kv := make([]string, 0, 2*len(m))
+	for _, k := range keys {
+		kv = append(kv, k, m[k])
+	}
+	return kv
+}
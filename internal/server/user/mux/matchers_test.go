@@ -0,0 +1,92 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mux
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func TestMethodsSplitsTheList(t *testing.T) {
+	if ms := Methods("GET, HEAD,POST"); !reflect.DeepEqual(ms, []string{"GET", "HEAD", "POST"}) {
+		t.Errorf("Unexpected methods %v", ms)
+	}
+}
+
+func TestMethodsReturnsNilForAnyMethod(t *testing.T) {
+	if ms := Methods("GET,*"); ms != nil {
+		t.Errorf("Unexpected methods %v", ms)
+	}
+}
+
+func TestNormalizeMethodReturnsTheCanonicalForm(t *testing.T) {
+	for method, expected := range map[string]string{
+		"GET":             "GET",
+		" post , get":     "GET,POST",
+		"GET,get,HEAD":    "GET,HEAD",
+		"HEAD,GET":        "GET,HEAD",
+		"get, *":          "*",
+		"DELETE,,PATCH ,": "DELETE,PATCH",
+	} {
+		if normalized := NormalizeMethod(method); normalized != expected {
+			t.Errorf("NormalizeMethod(%q) mismatch. Expected: %q, got: %q", method, expected, normalized)
+		}
+	}
+}
+
+func TestValidateMatchersAcceptsARouteWithoutMatchers(t *testing.T) {
+	if err := ValidateMatchers(model.Route{Method: "GET"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestValidateMatchersAcceptsValidMatchers(t *testing.T) {
+	r := model.Route{
+		Method:  "GET,HEAD",
+		Host:    "{sub}.example.com",
+		Headers: map[string]string{"Content-Type": "^application/json"},
+		Queries: map[string]string{"id": "{id:[0-9]+}"},
+		Schemes: []string{"HTTPS"},
+	}
+
+	if err := ValidateMatchers(r); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestValidateMatchersRejectsInvalidMatchers(t *testing.T) {
+	rs := map[string]model.Route{
+		"host":           {Host: "{sub"},
+		"header regexp":  {Headers: map[string]string{"Content-Type": "("}},
+		"header name":    {Headers: map[string]string{"": "foo"}},
+		"query pattern":  {Queries: map[string]string{"id": "{id"}},
+		"query name":     {Queries: map[string]string{"": "foo"}},
+		"unknown scheme": {Schemes: []string{"ftp"}},
+		"empty method":   {Method: "GET,,POST"},
+	}
+
+	for name, r := range rs {
+		if r.Method == "" {
+			r.Method = "GET"
+		}
+		if err := ValidateMatchers(r); err == nil {
+			t.Errorf("Invalid %s not detected", name)
+		}
+	}
+}
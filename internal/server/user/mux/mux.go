@@ -55,4 +55,5 @@ func (sm *SwappableMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func (sm *SwappableMux) Update(rs []model.Route) {
 	sm.set(gorillize(rs, handlerBuilder))
+	pruneLimiters(rs)
 }
@@ -0,0 +1,205 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// now is the clock used by the rate limiters
+var now = time.Now
+
+// bucket holds the tokens left to a client and when they were last counted
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter throttles the requests of a route with a token bucket per
+// client
+type rateLimiter struct {
+	settings model.RateLimit
+	// rate is the number of tokens added per second
+	rate  float64
+	burst float64
+
+	m         sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// rateLimiters holds the rate limiter of each route by its id, so the buckets
+// of the clients survive the route table updates
+var rateLimiters = struct {
+	sync.Mutex
+	byID map[string]*rateLimiter
+}{byID: map[string]*rateLimiter{}}
+
+// rateLimiterFor returns the rate limiter of r, or nil if r isn't rate
+// limited.  The current rate limiter of the route is reused as long as its
+// settings are the same.
+func rateLimiterFor(r model.Route) *rateLimiter {
+	if r.RateLimit == nil {
+		return nil
+	}
+	per, err := ratePeriod(*r.RateLimit)
+	if err != nil {
+		return nil
+	}
+
+	rateLimiters.Lock()
+	defer rateLimiters.Unlock()
+
+	rl, ok := rateLimiters.byID[r.ID]
+	if !ok || rl.settings != *r.RateLimit {
+		burst := r.RateLimit.Burst
+		if burst == 0 {
+			burst = r.RateLimit.Requests
+		}
+		rl = &rateLimiter{
+			settings:  *r.RateLimit,
+			rate:      float64(r.RateLimit.Requests) / per.Seconds(),
+			burst:     float64(burst),
+			buckets:   map[string]*bucket{},
+			lastSweep: now(),
+		}
+		rateLimiters.byID[r.ID] = rl
+	}
+	return rl
+}
+
+// ratePeriod returns the period of the requests allowed by rl
+func ratePeriod(rl model.RateLimit) (time.Duration, error) {
+	if rl.Per == "" {
+		return time.Second, nil
+	}
+	per, err := time.ParseDuration(rl.Per)
+	if err != nil || per <= 0 {
+		return 0, fmt.Errorf("invalid rate limit period %q", rl.Per)
+	}
+	return per, nil
+}
+
+// validateRateLimit checks that the settings of rl are consistent
+func validateRateLimit(rl model.RateLimit) error {
+	if rl.Requests <= 0 {
+		return errors.New("rate limit requests must be positive")
+	}
+	if rl.Burst < 0 {
+		return fmt.Errorf("negative rate limit burst %d", rl.Burst)
+	}
+	if rl.Key != "" && rl.Key != "ip" && (!strings.HasPrefix(rl.Key, "header:") || rl.Key == "header:") {
+		return fmt.Errorf("invalid rate limit key %q", rl.Key)
+	}
+	_, err := ratePeriod(rl)
+	return err
+}
+
+// clientKey returns the key of the client making req.  The requests lacking
+// the header used as key share a single bucket.
+func (rl *rateLimiter) clientKey(req *http.Request) string {
+	if strings.HasPrefix(rl.settings.Key, "header:") {
+		return req.Header.Get(strings.TrimPrefix(rl.settings.Key, "header:"))
+	}
+
+	if rl.settings.TrustedHeader != "" {
+		if v := req.Header.Get(rl.settings.TrustedHeader); v != "" {
+			addrs := strings.Split(v, ",")
+			if last := strings.TrimSpace(addrs[len(addrs)-1]); last != "" {
+				return last
+			}
+		}
+	}
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// allow takes a token from the bucket of key.  It returns whether there was
+// one, the tokens left and how long until the bucket is full again, or until
+// the next token when there was none.
+func (rl *rateLimiter) allow(key string) (bool, int, time.Duration) {
+	t := now()
+
+	rl.m.Lock()
+	defer rl.m.Unlock()
+
+	rl.sweep(t)
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rl.burst, last: t}
+		rl.buckets[key] = b
+	}
+	b.tokens = math.Min(rl.burst, b.tokens+t.Sub(b.last).Seconds()*rl.rate)
+	b.last = t
+
+	if b.tokens < 1 {
+		return false, 0, rl.wait(1 - b.tokens)
+	}
+	b.tokens--
+	return true, int(b.tokens), rl.wait(rl.burst - b.tokens)
+}
+
+// wait returns the time needed to get the given amount of tokens
+func (rl *rateLimiter) wait(tokens float64) time.Duration {
+	return time.Duration(tokens / rl.rate * float64(time.Second))
+}
+
+// sweep forgets the buckets that are full again, so idle clients don't take
+// memory forever.  It is done at most once per the time needed to fill a
+// bucket.
+func (rl *rateLimiter) sweep(t time.Time) {
+	fill := rl.wait(rl.burst)
+	if t.Sub(rl.lastSweep) < fill {
+		return
+	}
+	for k, b := range rl.buckets {
+		if t.Sub(b.last) >= fill {
+			delete(rl.buckets, k)
+		}
+	}
+	rl.lastSweep = t
+}
+
+// limit applies the rate limit to req, setting the rate limit headers of the
+// response.  It answers 429 Too Many Requests and returns false when the
+// client has no tokens left.
+func (rl *rateLimiter) limit(w http.ResponseWriter, req *http.Request) bool {
+	ok, remaining, wait := rl.allow(rl.clientKey(req))
+	seconds := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(int(rl.burst)))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("RateLimit-Reset", seconds)
+	if !ok {
+		w.Header().Set("Retry-After", seconds)
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+	}
+	return ok
+}
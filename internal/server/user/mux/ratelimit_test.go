@@ -0,0 +1,168 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+// fakeClock makes the rate limiters use a clock that only moves when told,
+// until restored
+func fakeClock() (advance func(time.Duration), restore func()) {
+	current := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	return func(d time.Duration) { current = current.Add(d) }, func() { now = time.Now }
+}
+
+func TestRateLimiterAllowsTheBurstAndThenRefills(t *testing.T) {
+	advance, restore := fakeClock()
+	defer restore()
+	defer pruneLimiters(nil)
+	rl := rateLimiterFor(model.Route{ID: "RATE", RateLimit: &model.RateLimit{Requests: 2, Per: "1m"}})
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := rl.allow("client"); !ok {
+			t.Fatalf("Request %d of the burst rejected", i)
+		}
+	}
+	ok, remaining, wait := rl.allow("client")
+	if ok || remaining != 0 || wait != 30*time.Second {
+		t.Errorf("Expected rejection with 30s to wait, got: %v, %d, %s", ok, remaining, wait)
+	}
+	if ok, _, _ := rl.allow("other"); !ok {
+		t.Error("Other client throttled")
+	}
+
+	advance(30 * time.Second)
+	if ok, _, _ := rl.allow("client"); !ok {
+		t.Error("Bucket not refilled")
+	}
+}
+
+func TestRateLimiterKeysByRemoteIP(t *testing.T) {
+	rl := &rateLimiter{}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	if key := rl.clientKey(req); key != "192.0.2.1" {
+		t.Errorf("Key mismatch. Expected: %q, got: %q", "192.0.2.1", key)
+	}
+}
+
+func TestRateLimiterKeysByTheLastAddressOfTheTrustedHeader(t *testing.T) {
+	rl := &rateLimiter{settings: model.RateLimit{TrustedHeader: "X-Forwarded-For"}}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.1")
+
+	if key := rl.clientKey(req); key != "198.51.100.1" {
+		t.Errorf("Key mismatch. Expected: %q, got: %q", "198.51.100.1", key)
+	}
+}
+
+func TestRateLimiterKeysByHeader(t *testing.T) {
+	rl := &rateLimiter{settings: model.RateLimit{Key: "header:X-Api-Key"}}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Api-Key", "s3cr3t")
+
+	if key := rl.clientKey(req); key != "s3cr3t" {
+		t.Errorf("Key mismatch. Expected: %q, got: %q", "s3cr3t", key)
+	}
+}
+
+func TestRateLimiterForKeepsTheBucketsWhileTheSettingsAreTheSame(t *testing.T) {
+	defer pruneLimiters(nil)
+	r := model.Route{ID: "RATE_KEEP", RateLimit: &model.RateLimit{Requests: 1}}
+
+	first := rateLimiterFor(r)
+	if rateLimiterFor(model.Route{ID: "RATE_KEEP", RateLimit: &model.RateLimit{Requests: 1}}) != first {
+		t.Error("Rate limiter not reused")
+	}
+	if rateLimiterFor(model.Route{ID: "RATE_KEEP", RateLimit: &model.RateLimit{Requests: 2}}) == first {
+		t.Error("Rate limiter not replaced when its settings changed")
+	}
+}
+
+func TestRateLimiterSweepsTheFullBuckets(t *testing.T) {
+	advance, restore := fakeClock()
+	defer restore()
+	defer pruneLimiters(nil)
+	rl := rateLimiterFor(model.Route{ID: "RATE_SWEEP", RateLimit: &model.RateLimit{Requests: 1}})
+	rl.allow("gone")
+
+	advance(time.Minute)
+	rl.allow("client")
+
+	if _, ok := rl.buckets["gone"]; ok {
+		t.Error("Idle bucket not swept")
+	}
+}
+
+func TestHandlerBuilder429sWhenRateLimited(t *testing.T) {
+	_, restore := fakeClock()
+	defer restore()
+	defer pruneLimiters(nil)
+	h := handlerBuilder(model.Route{ID: "RATE_429", RateLimit: &model.RateLimit{Requests: 1, Per: "10s", Burst: 1}})
+	rl := rateLimiterFor(model.Route{ID: "RATE_429", RateLimit: &model.RateLimit{Requests: 1, Per: "10s", Burst: 1}})
+	req := httptest.NewRequest("GET", "/", nil)
+	rl.allow(rl.clientKey(req))
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Status code mismatch. Expected: %d, got: %d", http.StatusTooManyRequests, w.Code)
+	}
+	for name, expected := range map[string]string{
+		"RateLimit-Limit":     "1",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "10",
+		"Retry-After":         "10",
+	} {
+		if v := w.Header().Get(name); v != expected {
+			t.Errorf("%s mismatch. Expected: %q, got: %q", name, expected, v)
+		}
+	}
+}
+
+func TestValidateLimitsChecksTheRateLimit(t *testing.T) {
+	testCases := []struct {
+		rl    model.RateLimit
+		valid bool
+	}{
+		{model.RateLimit{Requests: 10, Per: "1m", Burst: 5, Key: "header:X-Api-Key"}, true},
+		{model.RateLimit{Requests: 10, Key: "ip", TrustedHeader: "X-Forwarded-For"}, true},
+		{model.RateLimit{}, false},
+		{model.RateLimit{Requests: 1, Per: "0s"}, false},
+		{model.RateLimit{Requests: 1, Burst: -1}, false},
+		{model.RateLimit{Requests: 1, Key: "cookie"}, false},
+		{model.RateLimit{Requests: 1, Key: "header:"}, false},
+	}
+
+	for _, tc := range testCases {
+		rl := tc.rl
+		if err := ValidateLimits(model.Route{RateLimit: &rl}); (err == nil) != tc.valid {
+			t.Errorf("ValidateLimits(%+v) = %v, want valid %v", tc.rl, err, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mux
+
+import (
+	"fmt"
+
+	"github.com/gorilla/mux"
+
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user/auth"
+	"github.com/BBVA/kapow/internal/server/user/spawn"
+)
+
+// InvalidRouteError is returned by ValidateRoute, telling why a route is not
+// valid
+type InvalidRouteError struct {
+	// Field is the route field at fault, when the problem can be pinned
+	// to a single one
+	Field string
+
+	Reason string
+}
+
+func (e InvalidRouteError) Error() string {
+	return e.Reason
+}
+
+// ValidateRoute checks that the mandatory fields of r are present, that its
+// pattern and matchers comply with the gorilla mux requirements and that its
+// execution settings, limits and authentication can be applied.  Every route
+// is checked with it before being added to the route table, whether it comes
+// from the control API or from a route file.
+func ValidateRoute(r model.Route) error {
+	if r.Method == "" {
+		return InvalidRouteError{"method", `missing mandatory field "method"`}
+	}
+	if r.Pattern == "" {
+		return InvalidRouteError{"url_pattern", `missing mandatory field "url_pattern"`}
+	}
+	if err := mux.NewRouter().NewRoute().BuildOnly().Path(r.Pattern).GetError(); err != nil {
+		return InvalidRouteError{"url_pattern", fmt.Sprintf("invalid url_pattern: %v", err)}
+	}
+	if err := ValidateMatchers(r); err != nil {
+		return InvalidRouteError{"", fmt.Sprintf("invalid matchers: %v", err)}
+	}
+	if err := spawn.Validate(r); err != nil {
+		return InvalidRouteError{"", fmt.Sprintf("invalid execution settings: %v", err)}
+	}
+	if err := ValidateLimits(r); err != nil {
+		return InvalidRouteError{"", fmt.Sprintf("invalid limits: %v", err)}
+	}
+	if err := auth.Validate(r); err != nil {
+		return InvalidRouteError{"", fmt.Sprintf("invalid auth: %v", err)}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mux
+
+import (
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func TestValidateRouteAcceptsAValidRoute(t *testing.T) {
+	if err := ValidateRoute(model.Route{Method: "GET", Pattern: "/routes/{routeID}"}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestValidateRouteTellsTheFieldAtFault(t *testing.T) {
+	testCases := []struct {
+		name  string
+		route model.Route
+		field string
+	}{
+		{"MissingMethod", model.Route{Pattern: "/hello"}, "method"},
+		{"MissingPattern", model.Route{Method: "GET"}, "url_pattern"},
+		{"InvalidPattern", model.Route{Method: "GET", Pattern: "/routes/{routeID{"}, "url_pattern"},
+		{"InvalidMatchers", model.Route{Method: "GET", Pattern: "/hello", Schemes: []string{"ftp"}}, ""},
+		{"InvalidLimits", model.Route{Method: "GET", Pattern: "/hello", MaxQueue: 5}, ""},
+	}
+	for _, tc := range testCases {
+		err, ok := ValidateRoute(tc.route).(InvalidRouteError)
+		if !ok {
+			t.Errorf("%s: InvalidRouteError not returned", tc.name)
+		} else if err.Field != tc.field {
+			t.Errorf("%s: Field mismatch. Expected: %q, got: %q", tc.name, tc.field, err.Field)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/BBVA/kapow/internal/server/model"
+	"github.com/BBVA/kapow/internal/server/user/mux"
+)
+
+// Load replaces the current list of routes with the one stored in
+// stateFile and keeps persisting every further change into it.  A
+// nonexistent stateFile is not an error; it will be created on the first
+// change.
+func (srl *safeRouteList) Load(stateFile string) error {
+	rs := []model.Route{}
+
+	content, err := ioutil.ReadFile(stateFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Unable to read state file %s: %v", stateFile, err)
+	} else if err == nil {
+		if err = json.Unmarshal(content, &rs); err != nil {
+			return fmt.Errorf("Corrupt state file %s: %v", stateFile, err)
+		}
+	}
+
+	for i := range rs {
+		if rs[i].ID == "" {
+			return fmt.Errorf("Corrupt state file %s: route %d has no id", stateFile, i)
+		}
+		rs[i].Index = i
+	}
+
+	srl.m.Lock()
+	srl.rs = rs
+	srl.stateFile = stateFile
+	if err = srl.commit("load", ""); err != nil {
+		srl.stateFile = ""
+		srl.m.Unlock()
+		return err
+	}
+	srl.m.Unlock()
+
+	Server.Handler.(*mux.SwappableMux).Update(srl.Snapshot())
+
+	return nil
+}
+
+// ErrPersist is returned when a change of the list can't be written to the
+// state file.  The change is undone, so the list never diverges from the file.
+var ErrPersist = errors.New("Unable to persist routes")
+
+// persist writes the given list of routes to the state file, if any.  It
+// must be called while holding the write lock, so that writes are not
+// reordered.
+func (srl *safeRouteList) persist(rs []model.Route) error {
+	if srl.stateFile == "" {
+		return nil
+	}
+
+	content, _ := json.MarshalIndent(rs, "", "  ")
+	if err := writeFileAtomic(srl.stateFile, content); err != nil {
+		log.Printf("Unable to persist routes into %s: %v\n", srl.stateFile, err)
+		return ErrPersist
+	}
+	return nil
+}
+
+// writeFileAtomic writes content into a temporary file in the same directory
+// as path and then renames it to path, so a crash never leaves a partially
+// written file behind.
+func writeFileAtomic(path string, content []byte) error {
+	dir := filepath.Dir(path)
+
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	// Make the rename itself durable
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2019 Banco Bilbao Vizcaya Argentaria, S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package user
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BBVA/kapow/internal/server/model"
+)
+
+func TestLoadAcceptsANonexistentStateFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "kapow-test-state")
+	defer os.RemoveAll(dir)
+	srl := New()
+
+	if err := srl.Load(filepath.Join(dir, "routes.json")); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	if len(srl.rs) != 0 {
+		t.Error("Unexpected routes loaded")
+	}
+}
+
+func TestLoadReturnsAnErrorWhenTheStateFileIsCorrupt(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "kapow-test-state")
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "routes.json")
+	_ = ioutil.WriteFile(stateFile, []byte(`[{"id": "FOO", `), 0600)
+	srl := New()
+
+	err := srl.Load(stateFile)
+
+	if err == nil {
+		t.Fatal("Expected error not returned")
+	}
+	if !strings.Contains(err.Error(), stateFile) {
+		t.Errorf("Error doesn't mention the state file: %v", err)
+	}
+}
+
+func TestLoadReplacesTheRoutesWithTheStoredOnes(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "kapow-test-state")
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "routes.json")
+	_ = ioutil.WriteFile(stateFile, []byte(`[{"id": "FOO", "method": "GET", "url_pattern": "/foo"}, {"id": "BAR", "method": "GET", "url_pattern": "/bar"}]`), 0600)
+	srl := New()
+	srl.rs = append(srl.rs, model.Route{ID: "QUX"})
+
+	if err := srl.Load(stateFile); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if len(srl.rs) != 2 || srl.rs[0].ID != "FOO" || srl.rs[1].ID != "BAR" || srl.rs[1].Index != 1 {
+		t.Errorf("Routes not loaded properly: %+v", srl.rs)
+	}
+}
+
+func TestChangesArePersistedIntoTheStateFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "kapow-test-state")
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "routes.json")
+	srl := New()
+	_ = srl.Load(stateFile)
+
+	srl.Append(model.Route{ID: "FOO", Method: "GET", Pattern: "/foo"})
+	srl.Insert(model.Route{ID: "BAR", Method: "GET", Pattern: "/bar"})
+	_ = srl.Delete("FOO")
+
+	reloaded := New()
+	if err := reloaded.Load(stateFile); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(reloaded.rs) != 1 || reloaded.rs[0].ID != "BAR" {
+		t.Errorf("Changes not persisted: %+v", reloaded.rs)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("Temporary files left behind: %d files in state dir", len(files))
+	}
+}
+
+func TestChangesThatCannotBePersistedAreUndone(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "kapow-test-state")
+	defer os.RemoveAll(dir)
+	srl := New()
+	_ = srl.Load(filepath.Join(dir, "routes.json"))
+	srl.Append(model.Route{ID: "FOO"})
+	os.RemoveAll(dir)
+
+	if _, err := srl.Append(model.Route{ID: "BAR"}); err != ErrPersist {
+		t.Errorf("Unexpected error: got %v, want %v", err, ErrPersist)
+	}
+	if err := srl.Delete("FOO"); err != ErrPersist {
+		t.Errorf("Unexpected error: got %v, want %v", err, ErrPersist)
+	}
+
+	if len(srl.rs) != 1 || srl.rs[0].ID != "FOO" {
+		t.Errorf("Changes not undone: %+v", srl.rs)
+	}
+	if len(srl.History()) != 2 {
+		t.Errorf("Failed changes recorded in the history: %+v", srl.History())
+	}
+}
@@ -18,14 +18,12 @@ package user
 
 import (
 	"crypto/tls"
-	"crypto/x509"
-	"fmt"
-	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
 	"sync"
 
+	"github.com/BBVA/kapow/internal/server/certs"
 	"github.com/BBVA/kapow/internal/server/user/mux"
 )
 
@@ -34,12 +32,15 @@ var Server = http.Server{
 	Handler: mux.New(),
 }
 
-// Run finishes configuring Server and runs ListenAndServe on it
-func Run(bindAddr string, wg *sync.WaitGroup, certFile, keyFile, cliCaFile string, cliAuth bool) {
+// Run finishes configuring Server and runs ListenAndServe on it.  With
+// cliAuthOptional, client certificates are verified only when given, so
+// routes not requiring them can be served on the same listener.
+func Run(bindAddr string, wg *sync.WaitGroup, certFile, keyFile, cliCaFile string, cliAuth, cliAuthOptional bool) {
 	Server = http.Server{
 		Addr:    bindAddr,
 		Handler: mux.New(),
 	}
+	Server.Handler.(*mux.SwappableMux).Update(Routes.Snapshot())
 
 	listener, err := net.Listen("tcp", bindAddr)
 	if err != nil {
@@ -48,22 +49,15 @@ func Run(bindAddr string, wg *sync.WaitGroup, certFile, keyFile, cliCaFile strin
 
 	if (certFile != "") && (keyFile != "") {
 		if cliAuth {
-			if Server.TLSConfig == nil {
-				Server.TLSConfig = &tls.Config{}
+			clientAuth := tls.RequireAndVerifyClientCert
+			if cliAuthOptional {
+				clientAuth = tls.VerifyClientCertIfGiven
 			}
-
-			var err error
-			Server.TLSConfig.ClientCAs, err = loadCertificatesFromFile(cliCaFile)
+			Server.TLSConfig, err = certs.ClientAuthConfig(cliCaFile, clientAuth)
 			if err != nil {
 				log.Fatalf("UserServer failed to load CA certs: %s\n", err)
-			} else {
-				CAStore := "System store"
-				if Server.TLSConfig.ClientCAs != nil {
-					CAStore = cliCaFile
-				}
-				log.Printf("UserServer using CA certs from %s\n", CAStore)
-				Server.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
 			}
+			log.Printf("UserServer using CA certs from %s\n", certs.CAStoreName(cliCaFile))
 		}
 
 		// Signal startup
@@ -79,18 +73,3 @@ func Run(bindAddr string, wg *sync.WaitGroup, certFile, keyFile, cliCaFile strin
 		log.Fatal(Server.Serve(listener))
 	}
 }
-
-func loadCertificatesFromFile(certFile string) (pool *x509.CertPool, err error) {
-	if certFile != "" {
-		var caCerts []byte
-		caCerts, err = ioutil.ReadFile(certFile)
-		if err == nil {
-			pool = x509.NewCertPool()
-			if !pool.AppendCertsFromPEM(caCerts) {
-				err = fmt.Errorf("Invalid certificate file %s", certFile)
-			}
-		}
-	}
-
-	return
-}
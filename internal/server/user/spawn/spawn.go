@@ -18,15 +18,46 @@ package spawn
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/google/shlex"
 
 	"github.com/BBVA/kapow/internal/server/model"
 )
 
+// DefaultTimeout is the execution timeout of the routes that don't set their
+// own.  Zero means no timeout.
+var DefaultTimeout time.Duration
+
+// KillGracePeriod is the time given to the process group of a timed out
+// entrypoint to finish after SIGTERM, before being sent SIGKILL
+var KillGracePeriod = 5 * time.Second
+
+// ErrTimeout is returned by Spawn when the entrypoint doesn't finish within
+// the timeout of its route
+var ErrTimeout = errors.New("Execution timed out")
+
+// ErrDisconnected is returned by Spawn when the client went away while the
+// entrypoint was running, and it was sent the disconnect signal of its route
+var ErrDisconnected = errors.New("Client disconnected, execution signaled")
+
+// signals are the names of the signals that can be sent on disconnection
+var signals = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGALRM": syscall.SIGALRM,
+	"SIGTERM": syscall.SIGTERM,
+}
+
 func Spawn(h *model.Handler, stdout io.Writer, stderr io.Writer) error {
 	if h.Route.Entrypoint == "" {
 		return errors.New("Entrypoint cannot be empty")
@@ -35,6 +66,14 @@ func Spawn(h *model.Handler, stdout io.Writer, stderr io.Writer) error {
 	if err != nil {
 		return err
 	}
+	timeout, err := Timeout(h.Route)
+	if err != nil {
+		return err
+	}
+	sig, err := DisconnectSignal(h.Route)
+	if err != nil {
+		return err
+	}
 
 	if h.Route.Command != "" {
 		args = append(args, h.Route.Command)
@@ -48,9 +87,121 @@ func Spawn(h *model.Handler, stdout io.Writer, stderr io.Writer) error {
 		cmd.Stderr = stderr
 	}
 	cmd.Env = os.Environ()
+	cmd.Env = append(cmd.Env, routeEnv(h.Route.Env)...)
 	cmd.Env = append(cmd.Env, "KAPOW_HANDLER_ID="+h.ID)
+	if h.TmpDir != "" {
+		cmd.Env = append(cmd.Env, "KAPOW_TMPDIR="+h.TmpDir, "TMPDIR="+h.TmpDir)
+	}
+	cmd.Dir = h.Route.Workdir
+	setProcessGroup(cmd)
+
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var gone <-chan struct{}
+	if sig != 0 && h.Request != nil {
+		gone = h.Request.Context().Done()
+	}
+
+	disconnected := false
+	for {
+		select {
+		case err = <-done:
+			if disconnected {
+				return ErrDisconnected
+			}
+			return err
+		case <-expired:
+			terminate(cmd, done)
+			return ErrTimeout
+		case <-gone:
+			_ = signalGroup(cmd, sig)
+			disconnected = true
+			gone = nil
+		}
+	}
+}
+
+// terminate asks the process group of cmd to finish with SIGTERM, and sends
+// it SIGKILL if it is still running after KillGracePeriod.  It returns once
+// cmd has been waited for.
+func terminate(cmd *exec.Cmd, done <-chan error) {
+	_ = signalGroup(cmd, syscall.SIGTERM)
+	select {
+	case <-done:
+	case <-time.After(KillGracePeriod):
+		_ = signalGroup(cmd, syscall.SIGKILL)
+		<-done
+	}
+}
+
+// Timeout returns the execution timeout of r, which is DefaultTimeout unless
+// the route sets its own
+func Timeout(r model.Route) (time.Duration, error) {
+	if r.Timeout == "" {
+		return DefaultTimeout, nil
+	}
+	d, err := time.ParseDuration(r.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q", r.Timeout)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative timeout %q", r.Timeout)
+	}
+	return d, nil
+}
+
+// DisconnectSignal returns the signal to send to the entrypoint of r when the
+// client goes away, or zero if it must keep running
+func DisconnectSignal(r model.Route) (syscall.Signal, error) {
+	if r.DisconnectSignal == "" {
+		return 0, nil
+	}
+	name := strings.ToUpper(r.DisconnectSignal)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig, ok := signals[name]
+	if !ok {
+		return 0, fmt.Errorf("unsupported disconnect signal %q", r.DisconnectSignal)
+	}
+	return sig, nil
+}
 
-	err = cmd.Run()
+// routeEnv returns the variables in env as KEY=VALUE strings, in a stable
+// order
+func routeEnv(env map[string]string) []string {
+	vars := make([]string, 0, len(env))
+	for k, v := range env {
+		vars = append(vars, k+"="+v)
+	}
+	sort.Strings(vars)
+	return vars
+}
 
-	return err
+// Validate checks that the execution settings of r, its environment
+// variables, its timeout and its disconnect signal, can be applied
+func Validate(r model.Route) error {
+	for k := range r.Env {
+		if k == "" || strings.ContainsAny(k, "=\x00") {
+			return fmt.Errorf("invalid environment variable name %q", k)
+		}
+	}
+	if _, err := Timeout(r); err != nil {
+		return err
+	}
+	if _, err := DisconnectSignal(r); err != nil {
+		return err
+	}
+	return nil
 }
@@ -18,13 +18,18 @@ package spawn
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log"
+	"net/http/httptest"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/BBVA/kapow/internal/server/model"
 )
@@ -148,6 +153,230 @@ func TestSpawnSetsKapowHandlerIDEnvVar(t *testing.T) {
 	}
 }
 
+func TestSpawnSetsTheRouteEnvVars(t *testing.T) {
+	h := &model.Handler{
+		ID: "HANDLER_ID_FOO",
+		Route: model.Route{
+			Entrypoint: locateJailLover(),
+			Env: map[string]string{
+				"FOO":              "bar",
+				"KAPOW_DATA_URL":   "http://localhost:9092",
+				"KAPOW_HANDLER_ID": "HANDLER_ID_BAR",
+			},
+		},
+	}
+	out := &bytes.Buffer{}
+
+	os.Setenv("KAPOW_DATA_URL", "http://localhost:8082")
+
+	_ = Spawn(h, out, nil)
+
+	os.Unsetenv("KAPOW_DATA_URL")
+
+	jldata := decodeJailLover(out.Bytes())
+	if v := jldata.Env["FOO"]; v != "bar" {
+		t.Errorf("FOO is not set properly. Expected: %q, got: %q", "bar", v)
+	}
+	if v := jldata.Env["KAPOW_DATA_URL"]; v != "http://localhost:9092" {
+		t.Errorf("KAPOW_DATA_URL is not overridden. Expected: %q, got: %q", "http://localhost:9092", v)
+	}
+	if v := jldata.Env["KAPOW_HANDLER_ID"]; v != "HANDLER_ID_FOO" {
+		t.Errorf("KAPOW_HANDLER_ID overridden. Expected: %q, got: %q", "HANDLER_ID_FOO", v)
+	}
+}
+
+func TestSpawnSetsKapowTmpDirEnvVar(t *testing.T) {
+	h := &model.Handler{
+		Route: model.Route{
+			Entrypoint: locateJailLover(),
+		},
+		TmpDir: "/tmp/kapow-foo",
+	}
+	out := &bytes.Buffer{}
+
+	_ = Spawn(h, out, nil)
+
+	jldata := decodeJailLover(out.Bytes())
+	if v := jldata.Env["KAPOW_TMPDIR"]; v != "/tmp/kapow-foo" {
+		t.Errorf("KAPOW_TMPDIR is not set properly. Expected: %q, got: %q", "/tmp/kapow-foo", v)
+	}
+	if v := jldata.Env["TMPDIR"]; v != "/tmp/kapow-foo" {
+		t.Errorf("TMPDIR is not set properly. Expected: %q, got: %q", "/tmp/kapow-foo", v)
+	}
+}
+
+func TestSpawnRunsInTheRouteWorkdir(t *testing.T) {
+	dir := os.TempDir()
+	h := &model.Handler{
+		Route: model.Route{
+			Entrypoint: "/bin/sh -c",
+			Command:    "pwd -P",
+			Workdir:    dir,
+		},
+	}
+	out := &bytes.Buffer{}
+
+	if err := Spawn(h, out, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected, _ := filepath.EvalSymlinks(dir)
+	if got := strings.TrimSpace(out.String()); got != expected {
+		t.Errorf("Working directory mismatch. Expected: %q, got: %q", expected, got)
+	}
+}
+
+func TestSpawnReturnsErrTimeoutWhenTheTimeoutExpires(t *testing.T) {
+	h := &model.Handler{
+		Route: model.Route{
+			Entrypoint: "/bin/sh -c",
+			Command:    "sleep 10",
+			Timeout:    "100ms",
+		},
+	}
+
+	start := time.Now()
+	err := Spawn(h, nil, nil)
+
+	if err != ErrTimeout {
+		t.Errorf("Error mismatch. Expected: %v, got: %v", ErrTimeout, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Process not terminated on time, took %s", elapsed)
+	}
+}
+
+func TestSpawnKillsTheProcessGroupIgnoringSIGTERM(t *testing.T) {
+	origGrace := KillGracePeriod
+	defer func() { KillGracePeriod = origGrace }()
+	KillGracePeriod = 100 * time.Millisecond
+	h := &model.Handler{
+		Route: model.Route{
+			Entrypoint: "/bin/sh -c",
+			Command:    "trap '' TERM; sleep 10 & sleep 10; wait",
+			Timeout:    "100ms",
+		},
+	}
+	out := &bytes.Buffer{}
+
+	start := time.Now()
+	err := Spawn(h, out, nil)
+
+	if err != ErrTimeout {
+		t.Errorf("Error mismatch. Expected: %v, got: %v", ErrTimeout, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Process group not killed on time, took %s", elapsed)
+	}
+}
+
+func TestSpawnUsesTheDefaultTimeout(t *testing.T) {
+	origDefault := DefaultTimeout
+	defer func() { DefaultTimeout = origDefault }()
+	DefaultTimeout = 100 * time.Millisecond
+	h := &model.Handler{
+		Route: model.Route{
+			Entrypoint: "/bin/sh -c",
+			Command:    "sleep 10",
+		},
+	}
+
+	if err := Spawn(h, nil, nil); err != ErrTimeout {
+		t.Errorf("Error mismatch. Expected: %v, got: %v", ErrTimeout, err)
+	}
+}
+
+func TestSpawnSignalsTheProcessGroupWhenTheClientGoesAway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &model.Handler{
+		Route: model.Route{
+			Entrypoint:       "/bin/sh -c",
+			Command:          "sleep 10 & wait",
+			DisconnectSignal: "TERM",
+		},
+		Request: httptest.NewRequest("GET", "/", nil).WithContext(ctx),
+	}
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := Spawn(h, &bytes.Buffer{}, nil)
+
+	if err != ErrDisconnected {
+		t.Errorf("Error mismatch. Expected: %v, got: %v", ErrDisconnected, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Process group not signaled, took %s", elapsed)
+	}
+}
+
+func TestSpawnKeepsRunningWhenTheClientGoesAwayUnlessAsked(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h := &model.Handler{
+		Route: model.Route{
+			Entrypoint: "/bin/sh -c",
+			Command:    "sleep 0.2",
+		},
+		Request: httptest.NewRequest("GET", "/", nil).WithContext(ctx),
+	}
+
+	if err := Spawn(h, nil, nil); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestDisconnectSignalAcceptsSignalNames(t *testing.T) {
+	testCases := []struct {
+		name string
+		sig  syscall.Signal
+	}{
+		{"", 0},
+		{"SIGTERM", syscall.SIGTERM},
+		{"term", syscall.SIGTERM},
+		{"Kill", syscall.SIGKILL},
+		{"SIGINT", syscall.SIGINT},
+	}
+
+	for _, tc := range testCases {
+		sig, err := DisconnectSignal(model.Route{DisconnectSignal: tc.name})
+		if err != nil || sig != tc.sig {
+			t.Errorf("DisconnectSignal(%q) = %v, %v, want %v", tc.name, sig, err, tc.sig)
+		}
+	}
+	if _, err := DisconnectSignal(model.Route{DisconnectSignal: "SIGFOO"}); err == nil {
+		t.Error("Unsupported signal not reported")
+	}
+}
+
+func TestTimeoutPrefersTheRouteOne(t *testing.T) {
+	origDefault := DefaultTimeout
+	defer func() { DefaultTimeout = origDefault }()
+	DefaultTimeout = time.Minute
+
+	if d, _ := Timeout(model.Route{}); d != time.Minute {
+		t.Errorf("Default timeout not used, got: %s", d)
+	}
+	if d, _ := Timeout(model.Route{Timeout: "0"}); d != 0 {
+		t.Errorf("Route timeout not used, got: %s", d)
+	}
+	for _, timeout := range []string{"soon", "-1s"} {
+		if _, err := Timeout(model.Route{Timeout: timeout}); err == nil {
+			t.Errorf("Invalid timeout %q not reported", timeout)
+		}
+	}
+}
+
+func TestValidateEnvRejectsInvalidNames(t *testing.T) {
+	for _, name := range []string{"", "A=B", "A\x00B"} {
+		if err := Validate(model.Route{Env: map[string]string{name: "value"}}); err == nil {
+			t.Errorf("Invalid name %q not reported", name)
+		}
+	}
+	if err := Validate(model.Route{Env: map[string]string{"FOO_BAR": "a=b"}}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
 func TestSpawnRunsOKEntrypointsWithAParam(t *testing.T) {
 	h := &model.Handler{
 		Route: model.Route{
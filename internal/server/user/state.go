@@ -27,10 +27,28 @@ import (
 type safeRouteList struct {
 	rs []model.Route
 	m  *sync.RWMutex
+
+	// stateFile is the path where the list is persisted after every
+	// change.  No persistence is done when empty.
+	stateFile string
+
+	// history holds the last revisions of the list, oldest first.
+	history []model.Revision
+	// revision is the number of the last revision.
+	revision int
+	// committed is the list as of the last revision, used to tell the
+	// changes of the next one.
+	committed []model.Route
+
+	// subscribers receive the events of every change of the list.
+	subscribers map[chan model.Event]struct{}
 }
 
 var Routes safeRouteList = New()
 
+// ErrRouteNotFound is returned when the requested route ID is not in the list
+var ErrRouteNotFound = errors.New("Route not found")
+
 func New() safeRouteList {
 	return safeRouteList{
 		rs: []model.Route{},
@@ -38,15 +56,40 @@ func New() safeRouteList {
 	}
 }
 
-func (srl *safeRouteList) Append(r model.Route) model.Route {
+func (srl *safeRouteList) Append(r model.Route) (model.Route, error) {
 	srl.m.Lock()
 	r.Index = len(srl.rs)
 	srl.rs = append(srl.rs, r)
+	if err := srl.commit("append", r.ID); err != nil {
+		srl.m.Unlock()
+		return model.Route{}, err
+	}
 	srl.m.Unlock()
 
 	Server.Handler.(*mux.SwappableMux).Update(srl.Snapshot())
 
-	return r
+	return r, nil
+}
+
+func (srl *safeRouteList) Insert(r model.Route) (model.Route, error) {
+	srl.m.Lock()
+	if r.Index < 0 {
+		r.Index = 0
+	} else if r.Index > len(srl.rs) {
+		r.Index = len(srl.rs)
+	}
+	srl.rs = append(srl.rs, model.Route{})
+	copy(srl.rs[r.Index+1:], srl.rs[r.Index:])
+	srl.rs[r.Index] = r
+	if err := srl.commit("insert", r.ID); err != nil {
+		srl.m.Unlock()
+		return model.Route{}, err
+	}
+	srl.m.Unlock()
+
+	Server.Handler.(*mux.SwappableMux).Update(srl.Snapshot())
+
+	return r, nil
 }
 
 func (srl *safeRouteList) Snapshot() []model.Route {
@@ -72,6 +115,10 @@ func (srl *safeRouteList) Delete(ID string) error {
 	for i := 0; i < len(srl.rs); i++ {
 		if srl.rs[i].ID == ID {
 			srl.rs = append(srl.rs[:i], srl.rs[i+1:]...)
+			if err := srl.commit("delete", ID); err != nil {
+				srl.m.Unlock()
+				return err
+			}
 			srl.m.Unlock()
 			Server.Handler.(*mux.SwappableMux).Update(srl.Snapshot())
 			return nil
@@ -79,7 +126,65 @@ func (srl *safeRouteList) Delete(ID string) error {
 		}
 	}
 	srl.m.Unlock()
-	return errors.New("Route not found")
+	return ErrRouteNotFound
+}
+
+// Update applies fn to a copy of the route identified by ID and, if fn
+// succeeds, stores the result in the same position, keeping its ID.  The
+// whole operation is performed while holding the lock, so no request can
+// observe a state where the route is missing.
+func (srl *safeRouteList) Update(ID string, fn func(*model.Route) error) (model.Route, error) {
+	srl.m.Lock()
+	for i := 0; i < len(srl.rs); i++ {
+		if srl.rs[i].ID == ID {
+			r := srl.rs[i]
+			if err := fn(&r); err != nil {
+				srl.m.Unlock()
+				return model.Route{}, err
+			}
+			r.ID = ID
+			r.Index = i
+			srl.rs[i] = r
+			if err := srl.commit("update", ID); err != nil {
+				srl.m.Unlock()
+				return model.Route{}, err
+			}
+			srl.m.Unlock()
+			Server.Handler.(*mux.SwappableMux).Update(srl.Snapshot())
+			return r, nil
+		}
+	}
+	srl.m.Unlock()
+	return model.Route{}, ErrRouteNotFound
+}
+
+// Transaction calls fn with a copy of the current list of routes and, if fn
+// succeeds, replaces the list with the one returned by fn.  The mux is
+// updated only once, so no request can observe an intermediate state.
+func (srl *safeRouteList) Transaction(fn func([]model.Route) ([]model.Route, error)) ([]model.Route, error) {
+	srl.m.Lock()
+	rs := make([]model.Route, len(srl.rs))
+	copy(rs, srl.rs)
+	rs, err := fn(rs)
+	if err != nil {
+		srl.m.Unlock()
+		return nil, err
+	}
+	for i := 0; i < len(rs); i++ {
+		rs[i].Index = i
+	}
+	srl.rs = rs
+	if err = srl.commit("batch", ""); err != nil {
+		srl.m.Unlock()
+		return nil, err
+	}
+	srl.m.Unlock()
+
+	Server.Handler.(*mux.SwappableMux).Update(srl.Snapshot())
+
+	result := make([]model.Route, len(rs))
+	copy(result, rs)
+	return result, nil
 }
 
 func (srl *safeRouteList) Get(ID string) (r model.Route, err error) {
@@ -91,6 +196,6 @@ func (srl *safeRouteList) Get(ID string) (r model.Route, err error) {
 		}
 	}
 
-	err = errors.New("Route not found")
+	err = ErrRouteNotFound
 	return
 }
@@ -19,6 +19,7 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -166,7 +167,7 @@ func TestSnapshotNonBlockingReadWithOtherReaders(t *testing.T) {
 func TestAppendReturnsTheInsertedRouted(t *testing.T) {
 	srl := New()
 
-	r := srl.Append(model.Route{ID: "FOO"})
+	r, _ := srl.Append(model.Route{ID: "FOO"})
 
 	if r.ID != "FOO" {
 		t.Errorf(`ID of the returned route is not "FOO", but %q`, r.ID)
@@ -176,7 +177,7 @@ func TestAppendReturnsTheInsertedRouted(t *testing.T) {
 func TestAppendReturnsTheNumberedRoutesWhenEmpty(t *testing.T) {
 	srl := New()
 
-	r := srl.Append(model.Route{})
+	r, _ := srl.Append(model.Route{})
 
 	if r.Index != 0 {
 		t.Errorf("Index of the returned route is not 0, but %d", r.Index)
@@ -189,13 +190,63 @@ func TestAppendReturnsTheInsertedRoutedWithTheActualIndexWhenPopulated(t *testin
 	var r model.Route
 
 	for i := 0; i < 42; i++ {
-		r = srl.Append(model.Route{})
+		r, _ = srl.Append(model.Route{})
 	}
 	if r.Index != 42-1 {
 		t.Errorf("Index of the returned route is not the last one, i.e., 41, but %d", r.Index)
 	}
 }
 
+func TestInsertInsertsTheRouteAtTheGivenIndex(t *testing.T) {
+	srl := New()
+	srl.rs = append(srl.rs, model.Route{ID: "FOO"}, model.Route{ID: "QUX"})
+
+	srl.Insert(model.Route{ID: "BAR", Index: 1})
+
+	if len(srl.rs) != 3 || srl.rs[0].ID != "FOO" || srl.rs[1].ID != "BAR" || srl.rs[2].ID != "QUX" {
+		t.Errorf("Route not inserted in the right position: %+v", srl.rs)
+	}
+}
+
+func TestInsertInsertsInTheFirstPositionWhenIndexIsZero(t *testing.T) {
+	srl := New()
+	srl.rs = append(srl.rs, model.Route{ID: "FOO"})
+
+	r, _ := srl.Insert(model.Route{ID: "BAR"})
+
+	if r.Index != 0 || srl.rs[0].ID != "BAR" {
+		t.Errorf("Route not inserted in the first position: %+v", srl.rs)
+	}
+}
+
+func TestInsertClampsTheIndexToTheEndOfTheList(t *testing.T) {
+	srl := New()
+	srl.rs = append(srl.rs, model.Route{ID: "FOO"})
+
+	r, _ := srl.Insert(model.Route{ID: "BAR", Index: 42})
+
+	if r.Index != 1 {
+		t.Errorf("Index of the returned route is not 1, but %d", r.Index)
+	}
+	if len(srl.rs) != 2 || srl.rs[1].ID != "BAR" {
+		t.Errorf("Route not inserted in the last position: %+v", srl.rs)
+	}
+}
+
+func TestInsertAdquiresMutexBeforeInserting(t *testing.T) {
+	srl := New()
+
+	srl.m.Lock()
+	defer srl.m.Unlock()
+	go srl.Insert(model.Route{})
+
+	time.Sleep(10 * time.Millisecond)
+
+	if len(srl.rs) != 0 {
+		t.Error("Route inserted while mutex was acquired")
+	}
+}
+
 func TestListReturnsTheSameNumberOfRoutesThanSnapshot(t *testing.T) {
 	srl := New()
 	srl.Append(model.Route{ID: "FOO"})
@@ -341,7 +392,7 @@ func TestDeleteUpdatesMuxWithRemainingRoutes(t *testing.T) {
 		Handler: mux.New(),
 	}
 	srl := New()
-	route := srl.Append(
+	route, _ := srl.Append(
 		model.Route{
 			Method:     "GET",
 			Pattern:    "/",
@@ -431,3 +482,103 @@ func TestGetNonBlockingReadWithOtherReaders(t *testing.T) {
 		t.Error("Route list couldn't be readed while mutex was acquired for read")
 	}
 }
+
+func TestUpdateReturnsAnErrorWhenRouteNotExists(t *testing.T) {
+	srl := New()
+
+	if _, err := srl.Update("FOO", func(r *model.Route) error { return nil }); err != ErrRouteNotFound {
+		t.Errorf("Expected ErrRouteNotFound, got %v", err)
+	}
+}
+
+func TestUpdateKeepsTheIDAndPositionOfTheRoute(t *testing.T) {
+	srl := New()
+	srl.rs = append(srl.rs, model.Route{ID: "FOO"}, model.Route{ID: "BAR", Command: "echo bar"}, model.Route{ID: "QUX"})
+
+	r, err := srl.Update("BAR", func(r *model.Route) error {
+		*r = model.Route{ID: "BAZ", Command: "echo baz"}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if r.ID != "BAR" || r.Index != 1 || r.Command != "echo baz" {
+		t.Errorf("Returned route mismatch: %+v", r)
+	}
+	if srl.rs[1].ID != "BAR" || srl.rs[1].Command != "echo baz" {
+		t.Errorf("Route not updated in place: %+v", srl.rs)
+	}
+}
+
+func TestUpdateLeavesTheRouteUntouchedOnError(t *testing.T) {
+	srl := New()
+	srl.rs = append(srl.rs, model.Route{ID: "FOO", Command: "echo foo"})
+
+	_, err := srl.Update("FOO", func(r *model.Route) error {
+		r.Command = "echo bar"
+		return errors.New("Invalid")
+	})
+
+	if err == nil {
+		t.Error("Expected error not returned")
+	}
+	if srl.rs[0].Command != "echo foo" {
+		t.Error("Route modified when fn failed")
+	}
+}
+
+func TestUpdateWaitsForReadersToFinishReading(t *testing.T) {
+	srl := New()
+	srl.rs = append(srl.rs, model.Route{ID: "FOO"})
+
+	srl.m.RLock()
+	defer srl.m.RUnlock()
+
+	c := make(chan error)
+	go func() { _, err := srl.Update("FOO", func(r *model.Route) error { return nil }); c <- err }()
+
+	time.Sleep(10 * time.Millisecond)
+
+	select {
+	case <-c:
+		t.Error("Didn't wait for the reader to finish")
+	default:
+	}
+}
+
+func TestTransactionReplacesTheListWithTheReturnedOne(t *testing.T) {
+	srl := New()
+	srl.rs = append(srl.rs, model.Route{ID: "FOO"})
+
+	rs, err := srl.Transaction(func(rs []model.Route) ([]model.Route, error) {
+		return append(rs, model.Route{ID: "BAR"}), nil
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(srl.rs) != 2 || srl.rs[1].ID != "BAR" {
+		t.Errorf("List not replaced: %+v", srl.rs)
+	}
+	if len(rs) != 2 || rs[1].Index != 1 {
+		t.Errorf("Returned list not numbered: %+v", rs)
+	}
+}
+
+func TestTransactionLeavesTheListUntouchedOnError(t *testing.T) {
+	srl := New()
+	srl.rs = append(srl.rs, model.Route{ID: "FOO"})
+
+	_, err := srl.Transaction(func(rs []model.Route) ([]model.Route, error) {
+		rs[0].ID = "BAR"
+		return nil, errors.New("Invalid")
+	})
+
+	if err == nil {
+		t.Error("Expected error not returned")
+	}
+	if len(srl.rs) != 1 || srl.rs[0].ID != "FOO" {
+		t.Errorf("List modified when fn failed: %+v", srl.rs)
+	}
+}